@@ -154,24 +154,15 @@ func runCertUninstall(cmd *cobra.Command, args []string) error {
 }
 
 func runCertDetect(cmd *cobra.Command, args []string) error {
-	// Find compose file
-	var composePath string
-	var err error
-
-	if composeFile != "" {
-		composePath, err = filepath.Abs(composeFile)
-		if err != nil {
-			return fmt.Errorf("invalid compose file path: %w", err)
-		}
-	} else {
-		composePath, err = compose.FindComposeFile()
-		if err != nil {
-			return fmt.Errorf("no compose file found: %w", err)
-		}
+	// Find compose files
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return fmt.Errorf("no compose file found: %w", err)
 	}
+	composePath := composePaths[0]
 
-	// Parse compose file
-	composeData, err := compose.ParseComposeFile(composePath)
+	// Parse and merge compose files
+	composeData, err := loadComposeProject(composePaths, nil)
 	if err != nil {
 		return fmt.Errorf("failed to parse compose file: %w", err)
 	}
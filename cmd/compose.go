@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yejune/bootapp/internal/compose"
+	"github.com/yejune/bootapp/internal/network"
+)
+
+// resolveComposeFiles returns the compose files to use, in merge order:
+//  1. repeated -f flags
+//  2. COMPOSE_FILE (separated by COMPOSE_PATH_SEPARATOR, default ':' or ';' on Windows)
+//  3. auto-detection in --project-directory or the current directory, walking up
+//     to the repository root, plus its override file
+func resolveComposeFiles() ([]string, error) {
+	files := composeFiles
+	if len(files) == 0 {
+		if env := os.Getenv("COMPOSE_FILE"); env != "" {
+			sep := os.Getenv("COMPOSE_PATH_SEPARATOR")
+			if sep == "" {
+				sep = string(os.PathListSeparator)
+			}
+			for _, f := range strings.Split(env, sep) {
+				if f != "" {
+					files = append(files, f)
+				}
+			}
+		}
+	}
+
+	if len(files) > 0 {
+		var paths []string
+		for _, f := range files {
+			path, err := filepath.Abs(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid compose file path: %w", err)
+			}
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				return nil, fmt.Errorf("compose file not found: %s", path)
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	}
+
+	// Auto-detect
+	startDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if projectDirFlag != "" {
+		startDir = projectDirFlag
+	}
+	composePath, err := compose.FindComposeFileFrom(startDir)
+	if err != nil {
+		// Check if multiple files found
+		if multiErr, ok := err.(*compose.MultipleFilesError); ok {
+			composePath, err = chooseComposeFile(multiErr.Files)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+	}
+
+	paths := []string{composePath}
+	if override := compose.OverrideFile(composePath); override != "" {
+		paths = append(paths, override)
+	}
+	return paths, nil
+}
+
+// chooseComposeFile returns the file remembered for the directory of files,
+// or prompts for one and remembers the choice for later commands
+func chooseComposeFile(files []string) (string, error) {
+	dir := filepath.Dir(files[0])
+	choices := loadComposeChoices()
+	if name, ok := choices[dir]; ok {
+		for _, f := range files {
+			if filepath.Base(f) == name {
+				fmt.Printf("Using remembered compose file for %s (use -f to choose another)\n", dir)
+				return f, nil
+			}
+		}
+	}
+
+	composePath, err := selectComposeFile(files)
+	if err != nil {
+		return "", err
+	}
+
+	choices[dir] = filepath.Base(composePath)
+	if err := saveComposeChoices(choices); err != nil {
+		fmt.Printf("Warning: failed to remember compose file choice: %v\n", err)
+	}
+	return composePath, nil
+}
+
+// composeChoicesPath returns the file storing compose file choices per directory
+func composeChoicesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".bootapp", "compose-choices.json"), nil
+}
+
+// loadComposeChoices returns map[directory]compose file name
+func loadComposeChoices() map[string]string {
+	choices := make(map[string]string)
+	path, err := composeChoicesPath()
+	if err != nil {
+		return choices
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return choices
+	}
+	_ = json.Unmarshal(data, &choices)
+	return choices
+}
+
+func saveComposeChoices(choices map[string]string) error {
+	path, err := composeChoicesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(choices, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// projectDirectory returns --project-directory, or the first compose file's directory
+func projectDirectory(composePaths []string) string {
+	if projectDirFlag != "" {
+		if dir, err := filepath.Abs(projectDirFlag); err == nil {
+			return dir
+		}
+		return projectDirFlag
+	}
+	return filepath.Dir(composePaths[0])
+}
+
+// loadComposeProject builds the compose model using the selected --parser mode
+// Services outside the active profiles are dropped unless named in targets
+func loadComposeProject(composePaths []string, targets []string) (*compose.ComposeFile, error) {
+	var projectDir string
+	if projectDirFlag != "" {
+		projectDir = projectDirectory(composePaths)
+	}
+	composeData, warning, err := compose.LoadProject(composePaths, projectDir, parserMode)
+	if warning != "" {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inactive := compose.FilterActiveServices(composeData, compose.ActiveProfiles(profiles), targets)
+	if len(inactive) > 0 {
+		fmt.Printf("Inactive services (profiles): %s\n", strings.Join(inactive, ", "))
+	}
+	return composeData, nil
+}
+
+// projectIdentity is how bootapp knows the project in the current directory
+type projectIdentity struct {
+	// Name is the Compose project name, used for the registry, /etc/hosts
+	// markers and container label filters alike
+	Name string
+	// Base is the resolved name without the instance or a collision suffix,
+	// used for {project} in auto_domains
+	Base string
+	// SuffixedFrom is set when Name was suffixed because another directory
+	// registered it first
+	SuffixedFrom string
+	// Instance is the git worktree or branch instance (x-bootapp.instance)
+	Instance string
+	// AutoSuffix is set when the name comes from the directory, so a
+	// collision suffixes it instead of failing
+	AutoSuffix bool
+}
+
+// resolveProjectName returns the Compose project name
+func resolveProjectName(composePaths []string, composeData *compose.ComposeFile) (string, error) {
+	project, err := resolveProject(composePaths, composeData)
+	return project.Name, err
+}
+
+// resolveProject resolves the project name, adds the git instance and checks
+// the result against the registry, so two directories with the same name never
+// share a project
+// Names derived from the directory are suffixed (api-2); explicit names that
+// collide are refused
+func resolveProject(composePaths []string, composeData *compose.ComposeFile) (projectIdentity, error) {
+	projectDir := projectDirectory(composePaths)
+	resolved, err := compose.ResolveInstanceProject(projectFlag, projectDir, composeData)
+	if err != nil {
+		return projectIdentity{}, err
+	}
+	project := projectIdentity{
+		Name:       resolved.Name,
+		Base:       resolved.Base,
+		Instance:   resolved.Instance,
+		AutoSuffix: resolved.AutoSuffix(),
+	}
+
+	projectMgr, err := network.NewProjectManager()
+	if err != nil {
+		return projectIdentity{}, fmt.Errorf("failed to initialize project manager: %w", err)
+	}
+	name, suffixed, err := projectMgr.ResolveProject(resolved.Name, projectDir, project.AutoSuffix)
+	if err != nil {
+		return projectIdentity{}, err
+	}
+	if suffixed {
+		info, _ := projectMgr.GetProject(resolved.Name)
+		fmt.Printf("Note: project '%s' is registered for %s; using '%s' (set --project-name to choose)\n", resolved.Name, info.Path, name)
+		project.Name = name
+		project.SuffixedFrom = resolved.Name
+	}
+	return project, nil
+}
+
+// applyDomains generates x-bootapp.auto_domains, using the project name
+// without the instance for {project}, then prefixes every domain with the instance
+func (p projectIdentity) applyDomains(composeData *compose.ComposeFile) error {
+	if err := compose.ApplyDomainTemplate(composeData, p.Base); err != nil {
+		return err
+	}
+	compose.ApplyInstance(composeData, p.Instance)
+	return nil
+}
+
+// printProject prints the project name and its instance
+func (p projectIdentity) printProject(composeData *compose.ComposeFile) {
+	fmt.Printf("Project: %s\n", p.Name)
+	if p.Instance != "" {
+		fmt.Printf("Instance: %s (%s)\n", p.Instance, composeData.XBootapp.Instance)
+	}
+}
+
+// printComposeFiles prints the compose files in use
+func printComposeFiles(composePaths []string) {
+	if len(composePaths) == 1 {
+		fmt.Printf("Using compose file: %s\n", composePaths[0])
+		return
+	}
+	fmt.Println("Using compose files:")
+	for _, path := range composePaths {
+		fmt.Printf("  %s\n", path)
+	}
+}
+
+// composeArgs builds "compose -f <file>... -p <project> <subcommand...>"
+func composeArgs(composePaths []string, projectName string, subcommand ...string) []string {
+	args := []string{"compose"}
+	for _, path := range composePaths {
+		args = append(args, "-f", path)
+	}
+	if projectDirFlag != "" {
+		args = append(args, "--project-directory", projectDirectory(composePaths))
+	}
+	// COMPOSE_PROFILES is inherited from the environment
+	for _, profile := range profiles {
+		args = append(args, "--profile", profile)
+	}
+	args = append(args, "-p", projectName)
+	return append(args, subcommand...)
+}
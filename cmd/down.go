@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/yejune/bootapp/internal/compose"
 	"github.com/yejune/bootapp/internal/hosts"
 	"github.com/yejune/bootapp/internal/network"
 	"github.com/yejune/bootapp/internal/route"
@@ -55,45 +54,25 @@ func runDown(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Find or use specified docker-compose file
-	var composePath string
-	var err error
-
-	if composeFile != "" {
-		// Use specified file
-		composePath, err = filepath.Abs(composeFile)
-		if err != nil {
-			return fmt.Errorf("invalid compose file path: %w", err)
-		}
-		if _, err := os.Stat(composePath); os.IsNotExist(err) {
-			return fmt.Errorf("compose file not found: %s", composePath)
-		}
-	} else {
-		// Auto-detect
-		composePath, err = compose.FindComposeFile()
-		if err != nil {
-			// Check if multiple files found
-			if multiErr, ok := err.(*compose.MultipleFilesError); ok {
-				composePath, err = selectComposeFile(multiErr.Files)
-				if err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-		}
+	// Find or use specified docker-compose files
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Using compose file: %s\n", composePath)
+	printComposeFiles(composePaths)
 
-	// Parse compose file for project name
-	composeData, err := compose.ParseComposeFile(composePath)
+	// Parse compose files for project name
+	composeData, err := loadComposeProject(composePaths, args)
 	if err != nil {
 		return fmt.Errorf("failed to parse compose file: %w", err)
 	}
 
 	// Get project info
-	projectName := compose.GetProjectName(composePath, composeData)
+	projectName, err := resolveProjectName(composePaths, composeData)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Project: %s\n", projectName)
 
 	// Initialize project manager
@@ -108,15 +87,23 @@ func runDown(cmd *cobra.Command, args []string) error {
 	// Check if stopping individual services
 	stoppingIndividual := len(args) > 0
 
+	projectPath := projectDirectory(composePaths)
+	hooks := composeData.XBootapp.Hooks
+	if !stoppingIndividual {
+		if err := runHooks("pre_down", hooks.PreDown, projectPath, projectName); err != nil {
+			return err
+		}
+	}
+
 	// Run docker-compose down/stop
 	if stoppingIndividual {
 		fmt.Printf("\nStopping services: %v\n", args)
-		if err := runDockerComposeStop(composePath, projectName, args); err != nil {
+		if err := runDockerComposeStop(composePaths, projectName, args); err != nil {
 			return err
 		}
 	} else {
 		fmt.Println("\nStopping containers...")
-		if err := runDockerComposeDown(composePath, projectName); err != nil {
+		if err := runDockerComposeDown(composePaths, projectName); err != nil {
 			return err
 		}
 	}
@@ -149,6 +136,12 @@ func runDown(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if !stoppingIndividual {
+		if err := runHooks("post_down", hooks.PostDown, projectPath, projectName); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
 	if stoppingIndividual {
 		fmt.Println("\n✅ Services stopped")
 	} else {
@@ -159,9 +152,9 @@ func runDown(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runDockerComposeDown(composePath, projectName string) error {
+func runDockerComposeDown(composePaths []string, projectName string) error {
 	// Use "docker compose" (V2) instead of "docker-compose"
-	args := []string{"compose", "-f", composePath, "-p", projectName, "down"}
+	args := composeArgs(composePaths, projectName, "down")
 
 	if removeVolumes {
 		args = append(args, "-v")
@@ -171,7 +164,7 @@ func runDockerComposeDown(composePath, projectName string) error {
 	}
 
 	cmd := exec.Command("docker", args...)
-	cmd.Dir = filepath.Dir(composePath)
+	cmd.Dir = filepath.Dir(composePaths[0])
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -179,13 +172,13 @@ func runDockerComposeDown(composePath, projectName string) error {
 	return cmd.Run()
 }
 
-func runDockerComposeStop(composePath, projectName string, services []string) error {
+func runDockerComposeStop(composePaths []string, projectName string, services []string) error {
 	// Use "docker compose stop" for individual services (preserves containers)
-	args := []string{"compose", "-f", composePath, "-p", projectName, "stop"}
+	args := composeArgs(composePaths, projectName, "stop")
 	args = append(args, services...)
 
 	cmd := exec.Command("docker", args...)
-	cmd.Dir = filepath.Dir(composePath)
+	cmd.Dir = filepath.Dir(composePaths[0])
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
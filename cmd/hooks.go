@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runHooks runs x-bootapp hook commands in order from the project directory
+// Stops at the first failing command
+func runHooks(stage string, commands []string, projectPath, projectName string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\nRunning %s hooks...\n", stage)
+	for _, command := range commands {
+		fmt.Printf("  $ %s\n", command)
+
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = projectPath
+		cmd.Env = append(os.Environ(),
+			"BOOTAPP_PROJECT="+projectName,
+			"BOOTAPP_PROJECT_DIR="+projectPath,
+		)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook failed (%s): %w", stage, command, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/bootapp/internal/compose"
+)
+
+var lintStrict bool
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check compose files for bootapp problems",
+	Long: `Check compose files and report every problem as file:line:column:
+- Invalid domain names (RFC 1123)
+- .local domains (reserved for mDNS)
+- Duplicate domains across services
+- SSL domains that are not among a service's routed domains
+- Unsupported network configs (IPv6 static IPs, network_mode)
+- x-bootapp.network that is not a project network
+- Invalid ports entries
+- Unknown x-bootapp keys and bootapp.* labels
+
+Exits with an error if any errors are found (or warnings with --strict),
+so it can run as a pre-commit hook.`,
+	RunE:          runLint,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Treat warnings as errors")
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
+	}
+
+	var projectDir string
+	if projectDirFlag != "" {
+		projectDir = projectDirectory(composePaths)
+	}
+	diags, err := compose.Lint(composePaths, projectDir)
+	if err != nil {
+		return err
+	}
+
+	cwd, _ := os.Getwd()
+	errors, warnings := 0, 0
+	for _, d := range diags {
+		if rel, err := filepath.Rel(cwd, d.File); err == nil {
+			d.File = rel
+		}
+		fmt.Println(d.String())
+		if d.Severity == compose.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	if len(diags) == 0 {
+		fmt.Println("✓ No problems found")
+		return nil
+	}
+
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errors, warnings)
+	if errors > 0 || (lintStrict && warnings > 0) {
+		return fmt.Errorf("lint failed")
+	}
+	return nil
+}
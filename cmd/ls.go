@@ -43,15 +43,23 @@ func runLs(cmd *cobra.Command, args []string) error {
 	for name, info := range projects {
 		fmt.Printf("\n📦 %s\n", name)
 		fmt.Printf("   Path:   %s\n", info.Path)
-		fmt.Printf("   Subnet: %s\n", info.Subnet)
+		if projectMgr.NeedsMigration(info) {
+			fmt.Printf("   Subnet: %s (outside subnet pools, moved on next up)\n", info.Subnet)
+		} else {
+			fmt.Printf("   Subnet: %s\n", info.Subnet)
+		}
 
-		// Show domains (backward compatible with old Domain field)
-		domains := info.Domains
-		if len(domains) == 0 && info.Domain != "" {
-			domains = []string{info.Domain}
+		if len(info.Domains) > 0 {
+			fmt.Printf("   Domains: %s\n", strings.Join(info.Domains, ", "))
+		}
+		if info.Instance != "" {
+			fmt.Printf("   Instance: %s\n", info.Instance)
+		}
+		if info.BaseName != "" {
+			fmt.Printf("   Name:   suffixed from '%s' (registered for another directory)\n", info.BaseName)
 		}
-		if len(domains) > 0 {
-			fmt.Printf("   Domains: %s\n", strings.Join(domains, ", "))
+		for _, other := range projectMgr.Collisions(name) {
+			fmt.Printf("   ⚠️  Conflicts with '%s' (%s)\n", other, projects[other].Path)
 		}
 	}
 
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/bootapp/internal/network"
+)
+
+var registryDryRun bool
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the project registry",
+	Long:  `Inspect and maintain ~/.bootapp/projects.json.`,
+}
+
+var registryMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the registry to the current schema version",
+	Long: `Upgrade ~/.bootapp/projects.json to the current schema version.
+
+Other commands migrate the registry in memory and write it the next time
+they change it. The original file is kept as projects.json.v<N>.bak.`,
+	RunE: runRegistryMigrate,
+}
+
+func init() {
+	registryMigrateCmd.Flags().BoolVar(&registryDryRun, "dry-run", false, "Show the migrations without writing anything")
+	registryCmd.AddCommand(registryMigrateCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+func runRegistryMigrate(cmd *cobra.Command, args []string) error {
+	path, err := network.RegistryPath()
+	if err != nil {
+		return err
+	}
+
+	result, err := network.MigrateRegistryFile(path, registryDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+
+	if len(result.Applied) == 0 {
+		fmt.Printf("✓ %s is up to date (version %d)\n", path, result.To)
+		return nil
+	}
+
+	fmt.Printf("%s: version %d → %d\n", path, result.From, result.To)
+	for i, description := range result.Applied {
+		fmt.Printf("  %d. %s\n", result.From+i, description)
+	}
+	if registryDryRun {
+		fmt.Println("\nDry run, nothing was written")
+		return nil
+	}
+	fmt.Printf("\n✓ Migrated (backup: %s.v%d.bak)\n", path, result.From)
+	return nil
+}
@@ -4,10 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
-	"github.com/yejune/bootapp/internal/compose"
 )
 
 var restartCmd = &cobra.Command{
@@ -32,45 +30,28 @@ func init() {
 }
 
 func runRestart(cmd *cobra.Command, args []string) error {
-	// Find or use specified docker-compose file
-	var composePath string
-	var err error
-
-	if composeFile != "" {
-		composePath, err = filepath.Abs(composeFile)
-		if err != nil {
-			return fmt.Errorf("invalid compose file path: %w", err)
-		}
-		if _, err := os.Stat(composePath); os.IsNotExist(err) {
-			return fmt.Errorf("compose file not found: %s", composePath)
-		}
-	} else {
-		composePath, err = compose.FindComposeFile()
-		if err != nil {
-			if multiErr, ok := err.(*compose.MultipleFilesError); ok {
-				composePath, err = selectComposeFile(multiErr.Files)
-				if err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-		}
+	// Find or use specified docker-compose files
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Using compose file: %s\n", composePath)
+	printComposeFiles(composePaths)
 
-	// Parse compose file for project name
-	composeData, err := compose.ParseComposeFile(composePath)
+	// Parse compose files for project name
+	composeData, err := loadComposeProject(composePaths, args)
 	if err != nil {
 		return fmt.Errorf("failed to parse compose file: %w", err)
 	}
 
-	projectName := compose.GetProjectName(composePath, composeData)
+	projectName, err := resolveProjectName(composePaths, composeData)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Project: %s\n", projectName)
 
 	// Build docker compose restart command
-	dockerArgs := []string{"compose", "-f", composePath, "-p", projectName, "restart"}
+	dockerArgs := composeArgs(composePaths, projectName, "restart")
 
 	// Add specific services if provided
 	if len(args) > 0 {
@@ -81,7 +62,7 @@ func runRestart(cmd *cobra.Command, args []string) error {
 	}
 
 	dockerCmd := exec.Command("docker", dockerArgs...)
-	dockerCmd.Dir = filepath.Dir(composePath)
+	dockerCmd.Dir = projectDirectory(composePaths)
 	dockerCmd.Stdin = os.Stdin
 	dockerCmd.Stdout = os.Stdout
 	dockerCmd.Stderr = os.Stderr
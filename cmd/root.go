@@ -9,10 +9,15 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yejune/bootapp/internal/compose"
 )
 
 var (
-	composeFile string
+	composeFiles   []string
+	parserMode     string
+	profiles       []string
+	projectFlag    string
+	projectDirFlag string
 	// Version is set at build time via -ldflags
 	Version = "dev"
 )
@@ -30,7 +35,11 @@ registered in /etc/hosts pointing to the container IP.`,
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&composeFile, "file", "f", "", "Compose file (default: auto-detect)")
+	rootCmd.PersistentFlags().StringArrayVarP(&composeFiles, "file", "f", nil, "Compose file, may be repeated (default: COMPOSE_FILE or auto-detect)")
+	rootCmd.PersistentFlags().StringVarP(&projectFlag, "project-name", "p", "", "Project name (default: COMPOSE_PROJECT_NAME, 'name:' or the directory name)")
+	rootCmd.PersistentFlags().StringVar(&projectDirFlag, "project-directory", "", "Project directory (default: the first compose file's directory)")
+	rootCmd.PersistentFlags().StringArrayVar(&profiles, "profile", nil, "Enable a Compose profile, may be repeated (also COMPOSE_PROFILES)")
+	rootCmd.PersistentFlags().StringVar(&parserMode, "parser", compose.ParserAuto, "Compose model source: auto, docker (docker compose config) or yaml")
 }
 
 // Docker CLI Plugin metadata
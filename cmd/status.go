@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/bootapp/internal/compose"
+	"github.com/yejune/bootapp/internal/network"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current project's containers and URLs",
+	Long: `Show the subnet, container IPs and URLs of the current project.
+
+URLs combine each service's domains, published ports and the scheme
+inferred from SSL_DOMAINS.`,
+	RunE: runStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
+	}
+
+	composeData, err := loadComposeProject(composePaths, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	project, err := resolveProject(composePaths, composeData)
+	if err != nil {
+		return err
+	}
+	projectName := project.Name
+	project.printProject(composeData)
+
+	// Generate domains for services without any (x-bootapp.auto_domains)
+	if err := project.applyDomains(composeData); err != nil {
+		return err
+	}
+
+	projectMgr, err := network.NewProjectManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize project manager: %w", err)
+	}
+	if info, ok := projectMgr.GetProject(projectName); ok {
+		fmt.Printf("Subnet:  %s\n", info.Subnet)
+		if info.Subnet6 != "" {
+			fmt.Printf("IPv6:    %s\n", info.Subnet6)
+		}
+	} else {
+		fmt.Println("Subnet:  (not registered, run 'bootapp up')")
+	}
+
+	routable, err := compose.RoutableNetwork(composeData, compose.ProjectNetworks(composeData, projectName))
+	if err != nil {
+		return err
+	}
+	containerIPs, _, err := getContainerIPsAndSubnet(projectName, routable.DockerName)
+	if err != nil {
+		return fmt.Errorf("failed to get container IPs: %w", err)
+	}
+
+	fmt.Println("\nServices:")
+	serviceURLs := compose.ServiceURLs(composeData)
+	names := make([]string, 0, len(composeData.Services))
+	for name := range composeData.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if info, ok := containerIPs[name]; ok && info.IPv6 != "" {
+			fmt.Printf("  %s: %s, %s\n", name, info.IP, info.IPv6)
+		} else if ok {
+			fmt.Printf("  %s: %s\n", name, info.IP)
+		} else {
+			fmt.Printf("  %s: not running\n", name)
+		}
+		if urls := serviceURLs[name]; len(urls) > 0 {
+			fmt.Printf("    %s\n", strings.Join(urls, "\n    "))
+		}
+	}
+
+	return nil
+}
+
+// printServiceURLs prints the URLs of each service, sorted by service name
+func printServiceURLs(serviceURLs map[string][]string) {
+	if len(serviceURLs) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(serviceURLs))
+	for name := range serviceURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nURLs:")
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, strings.Join(serviceURLs[name], ", "))
+	}
+}
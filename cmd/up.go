@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ var (
 	pull          bool
 	detach        bool
 	forceRecreate bool
+	verifyAfterUp bool
 )
 
 var upCmd = &cobra.Command{
@@ -42,6 +44,8 @@ func init() {
 	upCmd.Flags().BoolVar(&pull, "pull", false, "Pull images before starting")
 	upCmd.Flags().BoolVarP(&detach, "detach", "d", true, "Run containers in background")
 	upCmd.Flags().BoolVarP(&forceRecreate, "force-recreate", "F", false, "Force recreate containers")
+	upCmd.Flags().BoolVar(&verifyAfterUp, "verify", false, "Verify HTTPS endpoints for SSL_DOMAINS after startup")
+	upCmd.Flags().IntVar(&verifyPort, "verify-port", 443, "HTTPS port for --verify (like verify --port)")
 	rootCmd.AddCommand(upCmd)
 }
 
@@ -56,39 +60,16 @@ func runUp(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Find or use specified docker-compose file
-	var composePath string
-	var err error
-
-	if composeFile != "" {
-		// Use specified file
-		composePath, err = filepath.Abs(composeFile)
-		if err != nil {
-			return fmt.Errorf("invalid compose file path: %w", err)
-		}
-		if _, err := os.Stat(composePath); os.IsNotExist(err) {
-			return fmt.Errorf("compose file not found: %s", composePath)
-		}
-	} else {
-		// Auto-detect
-		composePath, err = compose.FindComposeFile()
-		if err != nil {
-			// Check if multiple files found
-			if multiErr, ok := err.(*compose.MultipleFilesError); ok {
-				composePath, err = selectComposeFile(multiErr.Files)
-				if err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-		}
+	// Find or use specified docker-compose files
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Using compose file: %s\n", composePath)
+	printComposeFiles(composePaths)
 
-	// Parse compose file
-	composeData, err := compose.ParseComposeFile(composePath)
+	// Parse and merge compose files
+	composeData, err := loadComposeProject(composePaths, args)
 	if err != nil {
 		return fmt.Errorf("failed to parse compose file: %w", err)
 	}
@@ -97,13 +78,26 @@ func runUp(cmd *cobra.Command, args []string) error {
 	if err := compose.ValidateForBootapp(composeData); err != nil {
 		return fmt.Errorf("%s\n\n"+
 			"bootapp manages networks automatically and is intended for local development.\n"+
-			"Please remove network configurations from your compose file, or use 'docker compose' directly.", err)
+			"Please adjust the network configuration in your compose file, or use 'docker compose' directly.", err)
 	}
 
 	// Get project info
-	projectPath := filepath.Dir(composePath)
-	projectName := compose.GetProjectName(composePath, composeData)
-	fmt.Printf("Project: %s\n", projectName)
+	projectPath := projectDirectory(composePaths)
+	project, err := resolveProject(composePaths, composeData)
+	if err != nil {
+		return err
+	}
+	projectName := project.Name
+	project.printProject(composeData)
+	if project.Instance != "" {
+		warnInstanceConflicts(composeData)
+	}
+
+	// Generate domains for services without any (x-bootapp.auto_domains),
+	// prefixed with the instance
+	if err := project.applyDomains(composeData); err != nil {
+		return err
+	}
 
 	// Extract domains per service from compose file
 	serviceDomains := compose.ExtractServiceDomains(composeData)
@@ -122,7 +116,10 @@ func runUp(cmd *cobra.Command, args []string) error {
 	if len(sslDomains) > 0 {
 		fmt.Println("\nSetting up SSL certificates...")
 
-		// If force-recreate, delete existing certs and remove trust first
+		info := cert.DefaultCertInfo()
+		domainOutputs := collectCertOutputs(composeData, projectPath)
+
+		// If force-recreate, delete existing certs and their layouts and remove trust first
 		if forceRecreate {
 			fmt.Println("Force recreate: removing existing certificates...")
 			for _, domain := range sslDomains {
@@ -133,9 +130,9 @@ func runUp(cmd *cobra.Command, args []string) error {
 					} else {
 						fmt.Printf("  ✓ %s: untrusted\n", domain)
 					}
-					// Delete local cert files
-					if err := cert.RemoveCert(domain, certDir); err != nil {
-						fmt.Printf("  ⚠️  %s: failed to remove\n", domain)
+					// Delete local cert files and layouts
+					if err := cert.RemoveCert(domain, certDir, domainOutputs[domain]...); err != nil {
+						fmt.Printf("  ⚠️  %s: failed to remove: %v\n", domain, err)
 					} else {
 						fmt.Printf("  ✓ %s: removed\n", domain)
 					}
@@ -143,16 +140,24 @@ func runUp(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		info := cert.DefaultCertInfo()
 		for _, domain := range sslDomains {
+			outputs := domainOutputs[domain]
 			// Generate if not exists (or force recreate already deleted it)
 			if !cert.CertExists(domain, certDir) {
-				if err := cert.GenerateCert(domain, certDir, info); err != nil {
-					fmt.Printf("  ⚠️  %s: failed to generate\n", domain)
+				if err := cert.GenerateCert(domain, certDir, info, outputs...); err != nil {
+					fmt.Printf("  ⚠️  %s: failed to generate: %v\n", domain, err)
 					continue
 				}
 				fmt.Printf("  ✓ %s: generated\n", domain)
+				printCertOutputs(domain, outputs)
 				certsGenerated = true
+			} else if len(outputs) > 0 {
+				// Refresh server layouts from the existing key pair
+				if err := cert.WriteOutputs(domain, certDir, outputs); err != nil {
+					fmt.Printf("  ⚠️  %s: failed to write layouts: %v\n", domain, err)
+				} else {
+					printCertOutputs(domain, outputs)
+				}
 			}
 			// Collect certs that need trust
 			// -F (forceRecreate): always re-trust (certs were untrusted above)
@@ -169,6 +174,9 @@ func runUp(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize project manager: %w", err)
 	}
+	// New subnets skip ranges used by interfaces, routes and Docker networks
+	hostSubnets := network.HostSubnets()
+	projectMgr.SetHostSubnets(hostSubnets)
 
 	// Collect all domains from serviceDomains
 	allDomains := collectAllDomains(serviceDomains)
@@ -177,16 +185,89 @@ func runUp(cmd *cobra.Command, args []string) error {
 		allDomains = []string{projectName + ".local"}
 	}
 
-	// Get or create project configuration (allocates unique subnet)
-	projectInfo, changes, err := projectMgr.GetOrCreateProject(projectName, projectPath, allDomains, sslDomains)
+	// Get or create project configuration (allocates unique subnet unless pinned via x-bootapp.subnet)
+	pinnedSubnet := composeData.XBootapp.Subnet
+	projectMgr.AllowNameSuffix(project.AutoSuffix)
+	projectInfo, changes, err := projectMgr.GetOrCreateProjectWithSubnet(projectName, projectPath, allDomains, sslDomains, pinnedSubnet)
 	if err != nil {
 		return fmt.Errorf("failed to setup project: %w", err)
 	}
-	fmt.Printf("Subnet: %s\n", projectInfo.Subnet)
+	if changes.Name != projectName {
+		// Another directory registered the name while this one was starting
+		fmt.Printf("Note: project '%s' was just registered for another directory; using '%s'\n", projectName, changes.Name)
+		projectName = changes.Name
+		project.Name = changes.Name
+		project.SuffixedFrom = changes.SuffixedFrom
+	}
+	if err := projectMgr.SetNameOrigin(projectName, project.SuffixedFrom, project.Instance); err != nil {
+		return fmt.Errorf("failed to setup project: %w", err)
+	}
+	if pinnedSubnet != "" {
+		fmt.Printf("Subnet: %s (pinned)\n", projectInfo.Subnet)
+	} else {
+		fmt.Printf("Subnet: %s\n", projectInfo.Subnet)
+	}
+	if conflicts := network.Conflicts(projectInfo.Subnet, hostSubnets, projectName); len(conflicts) > 0 {
+		printSubnetConflicts(projectInfo.Subnet, conflicts, pinnedSubnet != "")
+	}
+	if changes.PreviousSubnet != "" {
+		fmt.Printf("Subnet moved from %s\n", changes.PreviousSubnet)
+		if err := route.RemoveRoute(changes.PreviousSubnet); err != nil {
+			fmt.Printf("Warning: Failed to remove old route: %v\n", err)
+		}
+	}
+
+	// IPv6 /64 from the machine's ULA prefix (x-bootapp.ipv6)
+	subnet6, err := projectMgr.SetIPv6(projectName, composeData.XBootapp.IPv6Enabled())
+	if err != nil {
+		return fmt.Errorf("failed to setup IPv6: %w", err)
+	}
+	if subnet6 != "" {
+		fmt.Printf("IPv6 subnet: %s\n", subnet6)
+	}
+
+	// Split the project subnet between the project networks
+	projectNetworks := compose.ProjectNetworks(composeData, projectName)
+	routable, err := compose.RoutableNetwork(composeData, projectNetworks)
+	if err != nil {
+		return err
+	}
+	networkSubnets, err := allocateNetworkSubnets(projectInfo.Subnet, projectNetworks)
+	if err != nil {
+		return fmt.Errorf("failed to allocate network subnets: %w", err)
+	}
+	printProjectNetworks(projectNetworks, networkSubnets, routable.Name)
+
+	// Give each service a stable address on the routable network, kept in the
+	// registry and applied through a generated compose override
+	serviceIPs, err := network.AssignServiceIPs(networkSubnets[routable.Name], routable.Services, routable.StaticIPs, projectInfo.ServiceIPs)
+	if err != nil {
+		return fmt.Errorf("failed to assign service IPs: %w", err)
+	}
+	if err := projectMgr.SetServiceIPs(projectName, serviceIPs); err != nil {
+		return fmt.Errorf("failed to save service IPs: %w", err)
+	}
+	override := compose.ServiceIPOverride{
+		Network:    routable.Name,
+		IPv4:       generatedServiceIPs(routable, serviceIPs),
+		IPv6:       make(map[string]string),
+		EnableIPv6: subnet6 != "",
+	}
+	if subnet6 != "" {
+		for name, ip := range override.IPv4 {
+			override.IPv6[name] = network.ServiceIPv6(subnet6, ip)
+		}
+	}
+	overridePath := projectMgr.OverridePath(projectName)
+	if err := compose.WriteServiceIPOverride(overridePath, override); err != nil {
+		return fmt.Errorf("failed to write compose override: %w", err)
+	}
 
 	// Clean up removed SSL domains (certs + trust)
 	if len(changes.RemovedSSLDomains) > 0 {
 		fmt.Println("\nCleaning up removed SSL domains...")
+		// A dropped domain no longer maps to a service, so look in every layout directory
+		layoutOutputs := projectCertOutputs(composeData, projectPath)
 		for _, domain := range changes.RemovedSSLDomains {
 			// Remove from trust store
 			if err := cert.UninstallFromTrustStore(domain); err != nil {
@@ -194,13 +275,15 @@ func runUp(cmd *cobra.Command, args []string) error {
 			} else {
 				fmt.Printf("  ✓ %s: untrusted\n", domain)
 			}
-			// Delete local cert files
+			// Delete local cert files and the layouts written for it
 			if cert.CertExists(domain, certDir) {
-				if err := cert.RemoveCert(domain, certDir); err != nil {
-					fmt.Printf("  ⚠️  %s: failed to remove cert\n", domain)
+				if err := cert.RemoveCert(domain, certDir, layoutOutputs...); err != nil {
+					fmt.Printf("  ⚠️  %s: failed to remove cert: %v\n", domain, err)
 				} else {
 					fmt.Printf("  ✓ %s: cert removed\n", domain)
 				}
+			} else if err := cert.RemoveOutputs(domain, layoutOutputs); err != nil {
+				fmt.Printf("  ⚠️  %s: failed to remove layouts: %v\n", domain, err)
 			}
 		}
 	}
@@ -238,22 +321,32 @@ func runUp(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Create the project networks up front so their sub-ranges are used
+	if err := ensureProjectNetworks(projectName, projectNetworks, networkSubnets, routable.Name, subnet6); err != nil {
+		return fmt.Errorf("failed to create networks: %w", err)
+	}
+
+	if err := runHooks("pre_up", composeData.XBootapp.Hooks.PreUp, projectPath, projectName); err != nil {
+		return err
+	}
+
 	// Run docker-compose up (force recreate if certs were newly generated or --force-recreate)
 	if len(args) > 0 {
 		fmt.Printf("\nStarting services: %v\n", args)
 	} else {
 		fmt.Println("\nStarting containers...")
 	}
-	if err := runDockerCompose(composePath, projectName, forceRecreate || certsGenerated, args); err != nil {
+	upPaths := append(append([]string(nil), composePaths...), overridePath)
+	if err := runDockerCompose(upPaths, projectName, forceRecreate || certsGenerated, args); err != nil {
 		return err
 	}
 
-	// Get container IPs and network info from default compose network
+	// Get container IPs and network info from the routable network
 	fmt.Println("\nDiscovering containers...")
-	containerIPs, networkSubnet, err := getContainerIPsAndSubnet(projectName)
+	containerIPs, networkSubnet, err := getContainerIPsAndSubnet(projectName, routable.DockerName)
 	if err != nil {
 		fmt.Printf("Warning: Could not get container IPs: %v\n", err)
-		containerIPs = make(map[string]string)
+		containerIPs = make(map[string]network.ContainerInfo)
 	}
 	if networkSubnet != "" {
 		fmt.Printf("Network subnet: %s\n", networkSubnet)
@@ -265,31 +358,35 @@ func runUp(cmd *cobra.Command, args []string) error {
 	// Print container info
 	fmt.Println("\nContainers:")
 	for name, info := range containers {
+		ip := info.IP
+		if info.IPv6 != "" {
+			ip += ", " + info.IPv6
+		}
 		if len(info.Domains) > 0 {
-			fmt.Printf("  %s: %s -> %s\n", name, strings.Join(info.Domains, ", "), info.IP)
+			fmt.Printf("  %s: %s -> %s\n", name, strings.Join(info.Domains, ", "), ip)
 		} else {
-			fmt.Printf("  %s: %s (no domain)\n", name, info.IP)
+			fmt.Printf("  %s: %s (no domain)\n", name, ip)
 		}
 	}
 
 	// Setup network aliases for domains (container-to-container communication)
 	if len(serviceDomains) > 0 {
 		fmt.Println("\nSetting up network aliases...")
-		networkName := projectName + "_default"
-		if err := setupNetworkAliases(projectName, networkName, serviceDomains); err != nil {
+		if err := setupNetworkAliases(projectName, routable, serviceIPs, serviceDomains); err != nil {
 			fmt.Printf("Warning: Failed to setup network aliases: %v\n", err)
 		}
 	}
 
 	// Setup /etc/hosts for all containers
 	fmt.Println("\nSetting up /etc/hosts...")
-	if err := hosts.AddEntries(containers, projectName); err != nil {
+	if err := hosts.AddEntries(reachableIPv6(containers), projectName); err != nil {
 		return fmt.Errorf("failed to update /etc/hosts: %w", err)
 	}
 
 	// Setup routing (macOS only) - use subnet from global config
 	fmt.Println("\nSetting up routing...")
-	// Get first container IP for connectivity test
+	// Get first container IP for connectivity test, or the routable network's
+	// default app address when no container reported one
 	var testIP string
 	for _, info := range containers {
 		if info.IP != "" {
@@ -297,10 +394,26 @@ func runUp(cmd *cobra.Command, args []string) error {
 			break
 		}
 	}
+	if testIP == "" {
+		testIP = network.GetDefaultIP(networkSubnets[routable.Name])
+	}
 	if err := route.SetupRouteWithTest(projectInfo.Subnet, testIP); err != nil {
 		fmt.Printf("Warning: Route setup failed: %v\n", err)
 	}
 
+	// Verify HTTPS endpoints (optional)
+	if verifyAfterUp && len(sslDomains) > 0 {
+		if failed := verifyEndpoints(sslDomains, certDir, verifyPort); failed > 0 {
+			fmt.Printf("Warning: %d of %d endpoints failed verification\n", failed, len(sslDomains))
+		}
+	}
+
+	if err := runHooks("post_up", composeData.XBootapp.Hooks.PostUp, projectPath, projectName); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	printServiceURLs(compose.ServiceURLs(composeData))
+
 	// Print config file location
 	fmt.Println("\n📁 Configuration: ~/.bootapp/projects.json")
 
@@ -313,7 +426,11 @@ func runUp(cmd *cobra.Command, args []string) error {
 	}
 
 	if appDomain != "" {
-		fmt.Printf("\n✅ Ready! Access your app at: https://%s\n", appDomain)
+		scheme := "http"
+		if containsDomain(sslDomains, appDomain) {
+			scheme = "https"
+		}
+		fmt.Printf("\n✅ Ready! Access your app at: %s://%s\n", scheme, appDomain)
 	} else {
 		fmt.Printf("\n✅ Ready!\n")
 	}
@@ -325,28 +442,21 @@ func runUp(cmd *cobra.Command, args []string) error {
 // serviceDomains: map[serviceName][]domains from compose file
 // Only services with explicit domain config get domains
 // Other services get IP only (no domain)
-func buildContainerInfo(ips map[string]string, serviceDomains map[string][]string) map[string]network.ContainerInfo {
+func buildContainerInfo(ips map[string]network.ContainerInfo, serviceDomains map[string][]string) map[string]network.ContainerInfo {
 	containers := make(map[string]network.ContainerInfo)
 
-	for name, ip := range ips {
-		// Check if this service has domain configuration
+	for name, info := range ips {
+		// Check if this service has domain configuration (no domain config - IP only)
 		if domains, ok := serviceDomains[name]; ok && len(domains) > 0 {
-			containers[name] = network.ContainerInfo{
-				Domains: domains,
-				IP:      ip,
-			}
-		} else {
-			// No domain config - IP only
-			containers[name] = network.ContainerInfo{
-				IP: ip,
-			}
+			info.Domains = domains
 		}
+		containers[name] = info
 	}
 
 	return containers
 }
 
-func createDockerNetwork(name, subnet string) error {
+func createDockerNetwork(name, subnet, subnet6, driver string, internal, attachable bool, labels ...string) error {
 	// Check if network already exists
 	checkCmd := exec.Command("docker", "network", "inspect", name)
 	if checkCmd.Run() == nil {
@@ -354,22 +464,174 @@ func createDockerNetwork(name, subnet string) error {
 		return nil // Keep existing network
 	}
 
+	if driver == "" {
+		driver = "bridge"
+	}
+
 	// Create network with subnet
 	args := []string{
 		"network", "create",
-		"--driver", "bridge",
+		"--driver", driver,
 		"--subnet", subnet,
-		name,
 	}
+	if subnet6 != "" {
+		args = append(args, "--ipv6", "--subnet", subnet6)
+	}
+	if internal {
+		args = append(args, "--internal")
+	}
+	if attachable {
+		args = append(args, "--attachable")
+	}
+	for _, label := range labels {
+		args = append(args, "--label", label)
+	}
+	args = append(args, name)
 	cmd := exec.Command("docker", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func runDockerCompose(composePath, projectName string, forceRecreate bool, services []string) error {
+// allocateNetworkSubnets assigns each project network a sub-range of the project subnet
+func allocateNetworkSubnets(projectSubnet string, projectNetworks []compose.ProjectNetwork) (map[string]string, error) {
+	requests := make([]network.NetworkRequest, len(projectNetworks))
+	for i, pn := range projectNetworks {
+		requests[i] = network.NetworkRequest{Name: pn.Name, Subnet: pn.Subnet, StaticIPs: pn.StaticIPs}
+	}
+	return network.AllocateNetworkSubnets(projectSubnet, requests)
+}
+
+// printProjectNetworks prints the subnet of each project network
+func printProjectNetworks(projectNetworks []compose.ProjectNetwork, subnets map[string]string, routable string) {
+	fmt.Println("Networks:")
+	for _, pn := range projectNetworks {
+		if pn.Name == routable {
+			fmt.Printf("  %s: %s (routable)\n", pn.Name, subnets[pn.Name])
+		} else {
+			fmt.Printf("  %s: %s\n", pn.Name, subnets[pn.Name])
+		}
+	}
+}
+
+// generatedServiceIPs returns the assigned addresses of the active services on
+// the routable network, leaving out the ones set in the compose file
+func generatedServiceIPs(routable compose.ProjectNetwork, serviceIPs map[string]string) map[string]string {
+	result := make(map[string]string)
+	for _, name := range routable.Services {
+		if _, ok := routable.StaticIPs[name]; ok {
+			continue
+		}
+		if ip := serviceIPs[name]; ip != "" {
+			result[name] = ip
+		}
+	}
+	return result
+}
+
+// printSubnetConflicts explains host ranges that overlap a registered project subnet
+func printSubnetConflicts(subnet string, conflicts []network.UsedSubnet, pinned bool) {
+	fmt.Printf("⚠️  Subnet %s overlaps ranges already in use:\n", subnet)
+	for _, c := range conflicts {
+		fmt.Printf("     %s\n", c)
+	}
+	fmt.Println("   Containers in the overlap may be unreachable from the host.")
+	if pinned {
+		fmt.Println("   Pin a free subnet with x-bootapp.subnet")
+	} else {
+		fmt.Println("   Run 'bootapp down --remove-config', then 'bootapp up' to allocate a free subnet")
+	}
+}
+
+// warnInstanceConflicts lists settings that every instance of a project shares
+// on the host, so only one instance can run at a time
+func warnInstanceConflicts(composeData *compose.ComposeFile) {
+	names := make([]string, 0, len(composeData.Services))
+	for name := range composeData.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var shared []string
+	for _, name := range names {
+		service := composeData.Services[name]
+		if service.ContainerName != "" {
+			shared = append(shared, fmt.Sprintf("%s: container_name %s", name, service.ContainerName))
+		}
+		for _, port := range service.Ports {
+			if port.Published != "" {
+				shared = append(shared, fmt.Sprintf("%s: host port %s", name, port.Published))
+			}
+		}
+	}
+	if len(shared) == 0 {
+		return
+	}
+	fmt.Println("⚠️  These are shared by all instances and will clash while another instance runs:")
+	for _, s := range shared {
+		fmt.Printf("     %s\n", s)
+	}
+	fmt.Println("   Reach services through their domains instead")
+}
+
+// reachableIPv6 drops the containers' IPv6 addresses when the host cannot
+// reach them, so /etc/hosts keeps the IPv4-mapped AAAA entry instead of one
+// clients would try first and fail on
+func reachableIPv6(containers map[string]network.ContainerInfo) map[string]network.ContainerInfo {
+	var testIP string
+	for _, info := range containers {
+		if info.IPv6 != "" {
+			testIP = info.IPv6
+			break
+		}
+	}
+	if testIP == "" || route.IPv6Reachable(testIP) {
+		return containers
+	}
+
+	fmt.Printf("  IPv6 %s is not reachable from this host; using IPv4-mapped addresses\n", testIP)
+	result := make(map[string]network.ContainerInfo, len(containers))
+	for name, info := range containers {
+		info.IPv6 = ""
+		result[name] = info
+	}
+	return result
+}
+
+// ensureProjectNetworks creates the project networks with their allocated subnets
+// The compose labels let docker compose adopt them as its own networks
+// subnet6, when set, enables IPv6 on the routable network
+func ensureProjectNetworks(projectName string, projectNetworks []compose.ProjectNetwork, subnets map[string]string, routable, subnet6 string) error {
+	for _, pn := range projectNetworks {
+		subnet := subnets[pn.Name]
+		var wantSubnet6 string
+		if pn.Name == routable {
+			wantSubnet6 = subnet6
+		}
+		if current, current6 := getNetworkSubnets(pn.DockerName); current != "" {
+			if current != subnet {
+				fmt.Printf("Warning: network %s uses %s, not %s (run 'bootapp down' to recreate it)\n", pn.DockerName, current, subnet)
+			}
+			if current6 != wantSubnet6 {
+				fmt.Printf("Warning: network %s has IPv6 subnet %q, not %q (run 'bootapp down' to recreate it)\n", pn.DockerName, current6, wantSubnet6)
+			}
+			continue
+		}
+
+		err := createDockerNetwork(pn.DockerName, subnet, wantSubnet6, pn.Driver, pn.Internal, pn.Attachable,
+			"com.docker.compose.project="+projectName,
+			"com.docker.compose.network="+pn.Name,
+		)
+		if err != nil {
+			return fmt.Errorf("network %s: %w", pn.Name, err)
+		}
+	}
+	return nil
+}
+
+func runDockerCompose(composePaths []string, projectName string, forceRecreate bool, services []string) error {
 	// Use "docker compose" (V2) instead of "docker-compose"
-	args := []string{"compose", "-f", composePath, "-p", projectName, "up"}
+	args := composeArgs(composePaths, projectName, "up")
 
 	if detach {
 		args = append(args, "-d")
@@ -390,7 +652,7 @@ func runDockerCompose(composePath, projectName string, forceRecreate bool, servi
 	}
 
 	cmd := exec.Command("docker", args...)
-	cmd.Dir = filepath.Dir(composePath)
+	cmd.Dir = filepath.Dir(composePaths[0])
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -466,7 +728,9 @@ func getContainerIPsFromNetwork(projectName, networkName string) (map[string]str
 }
 
 // getContainerIPsAndSubnet returns container IPs and the network subnet
-func getContainerIPsAndSubnet(projectName string) (map[string]string, string, error) {
+// IPs on routableNetwork are preferred; other services use any network they are on
+// IPv6 addresses are only taken from the routable network
+func getContainerIPsAndSubnet(projectName, routableNetwork string) (map[string]network.ContainerInfo, string, error) {
 	// List containers for this project
 	listCmd := exec.Command("docker", "ps", "-q", "--filter", fmt.Sprintf("label=com.docker.compose.project=%s", projectName))
 	output, err := listCmd.Output()
@@ -479,7 +743,7 @@ func getContainerIPsAndSubnet(projectName string) (map[string]string, string, er
 		return nil, "", fmt.Errorf("no containers found for project %s", projectName)
 	}
 
-	containers := make(map[string]string)
+	containers := make(map[string]network.ContainerInfo)
 	var networkName string
 
 	for _, id := range containerIDs {
@@ -497,16 +761,16 @@ func getContainerIPsAndSubnet(projectName string) (map[string]string, string, er
 		info := infos[0]
 		serviceName := info.Config.Labels["com.docker.compose.service"]
 
-		// Prefer *_default network
+		// Prefer the routable network
 		for netName, net := range info.NetworkSettings.Networks {
 			if net.IPAddress != "" && serviceName != "" {
-				if strings.HasSuffix(netName, "_default") {
-					containers[serviceName] = net.IPAddress
+				if netName == routableNetwork {
+					containers[serviceName] = network.ContainerInfo{IP: net.IPAddress, IPv6: net.GlobalIPv6Address}
 					networkName = netName
 					break
 				}
-				if containers[serviceName] == "" {
-					containers[serviceName] = net.IPAddress
+				if containers[serviceName].IP == "" {
+					containers[serviceName] = network.ContainerInfo{IP: net.IPAddress}
 					networkName = netName
 				}
 			}
@@ -514,24 +778,32 @@ func getContainerIPsAndSubnet(projectName string) (map[string]string, string, er
 	}
 
 	// Get subnet from the network
-	subnet := getNetworkSubnet(networkName)
+	subnet, _ := getNetworkSubnets(networkName)
 
 	return containers, subnet, nil
 }
 
-// getNetworkSubnet gets the subnet CIDR from a Docker network
-func getNetworkSubnet(networkName string) string {
+// getNetworkSubnets gets the IPv4 and IPv6 subnet CIDRs from a Docker network
+func getNetworkSubnets(networkName string) (string, string) {
 	if networkName == "" {
-		return ""
+		return "", ""
 	}
 
-	cmd := exec.Command("docker", "network", "inspect", networkName, "--format", "{{range .IPAM.Config}}{{.Subnet}}{{end}}")
+	cmd := exec.Command("docker", "network", "inspect", networkName, "--format", "{{range .IPAM.Config}}{{.Subnet}} {{end}}")
 	output, err := cmd.Output()
 	if err != nil {
-		return ""
+		return "", ""
 	}
 
-	return strings.TrimSpace(string(output))
+	var subnet, subnet6 string
+	for _, cidr := range strings.Fields(string(output)) {
+		if strings.Contains(cidr, ":") {
+			subnet6 = cidr
+		} else {
+			subnet = cidr
+		}
+	}
+	return subnet, subnet6
 }
 
 // DockerContainerInfo for JSON parsing
@@ -541,7 +813,8 @@ type DockerContainerInfo struct {
 	} `json:"Config"`
 	NetworkSettings struct {
 		Networks map[string]struct {
-			IPAddress string `json:"IPAddress"`
+			IPAddress         string `json:"IPAddress"`
+			GlobalIPv6Address string `json:"GlobalIPv6Address"`
 		} `json:"Networks"`
 	} `json:"NetworkSettings"`
 }
@@ -683,6 +956,63 @@ func ensureNetworkSetup() error {
 	return fmt.Errorf("docker-mac-net-connect is required but not installed\n\nInstall with:\n  brew install chipmk/tap/docker-mac-net-connect\n  sudo brew services start docker-mac-net-connect")
 }
 
+// collectCertOutputs maps each SSL domain to the certificate layouts requested
+// by its service's x-bootapp.certs block or bootapp.certs.* labels
+func collectCertOutputs(composeData *compose.ComposeFile, projectPath string) map[string][]cert.Output {
+	result := make(map[string][]cert.Output)
+	serviceSSLDomains := compose.ExtractServiceSSLDomains(composeData)
+
+	for serviceName, service := range composeData.Services {
+		if len(service.XBootapp.Certs) == 0 {
+			continue
+		}
+		for layout := range service.XBootapp.Certs {
+			if !cert.IsValidLayout(layout) {
+				fmt.Printf("  ⚠️  %s: unknown certificate layout '%s' (ignored)\n", serviceName, layout)
+				delete(service.XBootapp.Certs, layout)
+			}
+		}
+		outputs := cert.OutputsFromMap(service.XBootapp.Certs, projectPath)
+		for _, domain := range serviceSSLDomains[serviceName] {
+			result[domain] = append(result[domain], outputs...)
+		}
+	}
+
+	return result
+}
+
+// projectCertOutputs returns every certificate layout configured in the project
+func projectCertOutputs(composeData *compose.ComposeFile, projectPath string) []cert.Output {
+	seen := make(map[cert.Output]bool)
+	var result []cert.Output
+	for _, service := range composeData.Services {
+		for _, out := range cert.OutputsFromMap(service.XBootapp.Certs, projectPath) {
+			if cert.IsValidLayout(out.Layout) && !seen[out] {
+				seen[out] = true
+				result = append(result, out)
+			}
+		}
+	}
+	return result
+}
+
+// printCertOutputs prints the certificate layouts written for a domain
+func printCertOutputs(domain string, outputs []cert.Output) {
+	for _, out := range outputs {
+		fmt.Printf("  ✓ %s: %s layout in %s\n", domain, out.Layout, out.Dir)
+	}
+}
+
+// containsDomain reports whether domains includes domain
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
 // collectAllDomains collects all unique domains from serviceDomains map
 func collectAllDomains(serviceDomains map[string][]string) []string {
 	domainSet := make(map[string]bool)
@@ -701,10 +1031,17 @@ func collectAllDomains(serviceDomains map[string][]string) []string {
 
 // setupNetworkAliases adds network aliases for container-to-container communication
 // This allows containers to reach each other using hostnames defined in HOSTNAME/HOSTNAMES
-func setupNetworkAliases(projectName, networkName string, serviceHostnames map[string][]string) error {
+// Only services attached to the routable network get aliases; their assigned IPs are kept
+func setupNetworkAliases(projectName string, routable compose.ProjectNetwork, serviceIPs map[string]string, serviceHostnames map[string][]string) error {
+	networkName := routable.DockerName
+	attached := make(map[string]bool)
+	for _, name := range routable.Services {
+		attached[name] = true
+	}
+
 	// Get container IDs for each service
 	for serviceName, hostnames := range serviceHostnames {
-		if len(hostnames) == 0 {
+		if len(hostnames) == 0 || !attached[serviceName] {
 			continue
 		}
 
@@ -725,6 +1062,9 @@ func setupNetworkAliases(projectName, networkName string, serviceHostnames map[s
 		for _, hostname := range hostnames {
 			args = append(args, "--alias", hostname)
 		}
+		if ip := serviceIPs[serviceName]; ip != "" {
+			args = append(args, "--ip", ip)
+		}
 		args = append(args, networkName, containerID)
 
 		connectCmd := exec.Command("docker", args...)
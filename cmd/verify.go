@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yejune/bootapp/internal/cert"
+	"github.com/yejune/bootapp/internal/compose"
+)
+
+var verifyPort int
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify HTTPS endpoints for SSL_DOMAINS",
+	Long: `Open a TLS connection to each SSL_DOMAINS entry at its resolved IP and check:
+- Certificate chain (system roots + bootapp certificates in var/certs)
+- SAN matches the domain
+- Certificate expiry
+- HTTP status of "HEAD /"
+
+Exits with an error if any endpoint fails.`,
+	RunE: runVerify,
+}
+
+func init() {
+	verifyCmd.Flags().IntVar(&verifyPort, "port", 443, "HTTPS port to connect to")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	// Find or use specified docker-compose files
+	composePaths, err := resolveComposeFiles()
+	if err != nil {
+		return err
+	}
+
+	printComposeFiles(composePaths)
+
+	composeData, err := loadComposeProject(composePaths, nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	project, err := resolveProject(composePaths, composeData)
+	if err != nil {
+		return err
+	}
+	if err := project.applyDomains(composeData); err != nil {
+		return err
+	}
+
+	sslDomains := compose.ExtractSSLDomains(composeData)
+	if len(sslDomains) == 0 {
+		fmt.Println("No SSL_DOMAINS found in compose file")
+		return nil
+	}
+
+	certDir := filepath.Join(projectDirectory(composePaths), "var", "certs")
+	if failed := verifyEndpoints(sslDomains, certDir, verifyPort); failed > 0 {
+		return fmt.Errorf("%d of %d endpoints failed verification", failed, len(sslDomains))
+	}
+	return nil
+}
+
+// verifyEndpoints checks each domain over HTTPS and prints a report
+// Returns the number of domains that failed any check
+func verifyEndpoints(domains []string, certDir string, port int) int {
+	fmt.Println("\nVerifying HTTPS endpoints...")
+
+	roots, err := cert.LoadRoots(certDir)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to load certificates from %s: %v\n", certDir, err)
+		return len(domains)
+	}
+
+	failed := 0
+	for _, domain := range domains {
+		ip, err := resolveDomainIP(domain)
+		if err != nil {
+			fmt.Printf("  ✗ %s: cannot resolve: %v\n", domain, err)
+			failed++
+			continue
+		}
+
+		addr := net.JoinHostPort(ip, strconv.Itoa(port))
+		result := cert.VerifyEndpoint(domain, addr, roots)
+		if !printVerifyResult(result, certDir) {
+			failed++
+		}
+	}
+	return failed
+}
+
+// printVerifyResult prints the report for one endpoint and returns true if it passed
+func printVerifyResult(r *cert.VerifyResult, certDir string) bool {
+	if r.Err != nil && r.Leaf == nil {
+		fmt.Printf("  ✗ %s (%s): %v\n", r.Domain, r.Addr, r.Err)
+		return false
+	}
+
+	ok := r.OK()
+	mark := "✓"
+	if !ok {
+		mark = "✗"
+	}
+	fmt.Printf("  %s %s (%s)\n", mark, r.Domain, r.Addr)
+
+	if r.ChainValid {
+		fmt.Println("      chain:  trusted")
+	} else {
+		fmt.Printf("      chain:  untrusted (%s)\n", r.ChainError)
+	}
+
+	// Detect a server presenting some other certificate than the one bootapp generated
+	if local, err := cert.LoadCertificate(r.Domain, certDir); err == nil && !local.Equal(r.Leaf) {
+		fmt.Printf("      served: not the bootapp certificate in %s\n", certDir)
+	}
+
+	if r.SANMatch {
+		fmt.Printf("      SAN:    match (%s)\n", strings.Join(r.SANs, ", "))
+	} else {
+		fmt.Printf("      SAN:    mismatch (certificate has %s)\n", strings.Join(r.SANs, ", "))
+	}
+
+	if r.Expired {
+		fmt.Printf("      expiry: expired on %s\n", r.NotAfter.Format("2006-01-02"))
+	} else {
+		days := int(time.Until(r.NotAfter).Hours() / 24)
+		fmt.Printf("      expiry: %s (%d days left)\n", r.NotAfter.Format("2006-01-02"), days)
+	}
+
+	if r.Err != nil {
+		fmt.Printf("      HTTP:   %v\n", r.Err)
+	} else {
+		fmt.Printf("      HTTP:   %d\n", r.StatusCode)
+	}
+
+	return ok
+}
+
+// resolveDomainIP resolves a domain (via /etc/hosts or DNS) to an IPv4 address
+func resolveDomainIP(domain string) (string, error) {
+	ips, err := net.LookupIP(domain)
+	if err != nil {
+		return "", err
+	}
+	for _, ip := range ips {
+		// Also unwraps ::ffff: IPv4-mapped entries written by bootapp
+		if v4 := ip.To4(); v4 != nil {
+			return v4.String(), nil
+		}
+	}
+	if len(ips) > 0 {
+		return ips[0].String(), nil
+	}
+	return "", fmt.Errorf("no addresses found")
+}
@@ -41,7 +41,8 @@ func DefaultCertInfo() CertInfo {
 }
 
 // GenerateCert creates a self-signed certificate for the given domain
-func GenerateCert(domain, certDir string, info CertInfo) error {
+// Optional outputs are written in their server-specific layouts as well
+func GenerateCert(domain, certDir string, info CertInfo, outputs ...Output) error {
 	if err := os.MkdirAll(certDir, 0755); err != nil {
 		return fmt.Errorf("failed to create cert directory: %w", err)
 	}
@@ -99,7 +100,7 @@ func GenerateCert(domain, certDir string, info CertInfo) error {
 	pem.Encode(pemFile, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyDER})
 	pemFile.Close()
 
-	return nil
+	return WriteOutputs(domain, certDir, outputs)
 }
 
 // CertExists checks if certificate exists
@@ -130,9 +131,10 @@ func ListCerts(certDir string) ([]string, error) {
 }
 
 // RemoveCert removes certificate files
-func RemoveCert(domain, certDir string) error {
+// Optional outputs have their server-specific layouts removed as well
+func RemoveCert(domain, certDir string, outputs ...Output) error {
 	for _, ext := range []string{".crt", ".key", ".pem"} {
 		os.Remove(filepath.Join(certDir, domain+ext))
 	}
-	return nil
+	return RemoveOutputs(domain, outputs)
 }
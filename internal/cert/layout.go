@@ -0,0 +1,175 @@
+package cert
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+)
+
+// Supported certificate output layouts
+const (
+	LayoutNginx   = "nginx"   // <dir>/<domain>/fullchain.pem + privkey.pem
+	LayoutHAProxy = "haproxy" // <dir>/<domain>.pem (cert + key)
+	LayoutApache  = "apache"  // <dir>/<domain>.crt, .key, -chain.crt
+	LayoutJava    = "java"    // <dir>/<domain>.p12 (PKCS#12 keystore)
+)
+
+// JavaKeystorePassword is the password used for generated PKCS#12 keystores
+// (same as the JDK default truststore password)
+const JavaKeystorePassword = "changeit"
+
+// Output describes a server-specific copy of a certificate
+type Output struct {
+	Layout string
+	Dir    string
+}
+
+// IsValidLayout checks if layout is a supported output layout
+func IsValidLayout(layout string) bool {
+	switch layout {
+	case LayoutNginx, LayoutHAProxy, LayoutApache, LayoutJava:
+		return true
+	}
+	return false
+}
+
+// OutputsFromMap converts a layout -> directory mapping into outputs
+// Relative directories are resolved against baseDir
+// Outputs are sorted by layout for stable ordering
+func OutputsFromMap(layouts map[string]string, baseDir string) []Output {
+	var outputs []Output
+	for layout, dir := range layouts {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		outputs = append(outputs, Output{Layout: layout, Dir: dir})
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Layout < outputs[j].Layout
+	})
+	return outputs
+}
+
+// WriteOutputs writes server-specific layouts from an existing certificate
+// Keys are never regenerated; the .crt/.key files in certDir are reused
+func WriteOutputs(domain, certDir string, outputs []Output) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+
+	sslname := filepath.Join(certDir, domain)
+	certPEM, err := os.ReadFile(sslname + ".crt")
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(sslname + ".key")
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	// One failing layout does not keep the others on an older certificate
+	var errs []error
+	for _, out := range outputs {
+		if err := writeOutput(domain, sslname, certPEM, keyPEM, out); err != nil {
+			errs = append(errs, fmt.Errorf("%s layout: %w", out.Layout, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func writeOutput(domain, sslname string, certPEM, keyPEM []byte, out Output) error {
+	if err := os.MkdirAll(out.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Self-signed certificates are their own issuer, so the chain is the cert itself
+	switch out.Layout {
+	case LayoutNginx:
+		dir := filepath.Join(out.Dir, domain)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		return writeFiles(map[string][]byte{
+			filepath.Join(dir, "fullchain.pem"): certPEM,
+			filepath.Join(dir, "privkey.pem"):   keyPEM,
+		})
+	case LayoutHAProxy:
+		combined := append(append([]byte{}, certPEM...), keyPEM...)
+		return writeFiles(map[string][]byte{
+			filepath.Join(out.Dir, domain+".pem"): combined,
+		})
+	case LayoutApache:
+		return writeFiles(map[string][]byte{
+			filepath.Join(out.Dir, domain+".crt"):       certPEM,
+			filepath.Join(out.Dir, domain+".key"):       keyPEM,
+			filepath.Join(out.Dir, domain+"-chain.crt"): certPEM,
+		})
+	case LayoutJava:
+		return writeKeystore(domain, sslname, filepath.Join(out.Dir, domain+".p12"))
+	default:
+		return fmt.Errorf("unknown layout: %s", out.Layout)
+	}
+}
+
+// RemoveOutputs removes the files written for domain in each layout, so a
+// dropped or re-created certificate leaves no stale copies behind
+// Files that do not exist are skipped
+func RemoveOutputs(domain string, outputs []Output) error {
+	for _, out := range outputs {
+		var paths []string
+		switch out.Layout {
+		case LayoutNginx:
+			dir := filepath.Join(out.Dir, domain)
+			paths = []string{filepath.Join(dir, "fullchain.pem"), filepath.Join(dir, "privkey.pem")}
+			// The domain directory goes too unless it holds other files
+			defer os.Remove(dir)
+		case LayoutHAProxy:
+			paths = []string{filepath.Join(out.Dir, domain+".pem")}
+		case LayoutApache:
+			paths = []string{
+				filepath.Join(out.Dir, domain+".crt"),
+				filepath.Join(out.Dir, domain+".key"),
+				filepath.Join(out.Dir, domain+"-chain.crt"),
+			}
+		case LayoutJava:
+			paths = []string{filepath.Join(out.Dir, domain+".p12")}
+		}
+		for _, path := range paths {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("%s layout: %w", out.Layout, err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeFiles(files map[string][]byte) error {
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeKeystore creates a PKCS#12 keystore using openssl
+// Java 9+ reads PKCS#12 keystores natively (keystore type "PKCS12")
+func writeKeystore(domain, sslname, dest string) error {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		return fmt.Errorf("openssl is required for java keystores")
+	}
+
+	cmd := exec.Command("openssl", "pkcs12", "-export",
+		"-in", sslname+".crt",
+		"-inkey", sslname+".key",
+		"-name", domain,
+		"-out", dest,
+		"-passout", "pass:"+JavaKeystorePassword)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("openssl pkcs12 failed: %w: %s", err, output)
+	}
+	return nil
+}
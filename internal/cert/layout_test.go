@@ -0,0 +1,222 @@
+package cert
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsValidLayout(t *testing.T) {
+	tests := []struct {
+		layout   string
+		expected bool
+	}{
+		{"nginx", true},
+		{"haproxy", true},
+		{"apache", true},
+		{"java", true},
+		{"caddy", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			if result := IsValidLayout(tt.layout); result != tt.expected {
+				t.Errorf("IsValidLayout(%q) = %v, want %v", tt.layout, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputsFromMap(t *testing.T) {
+	outputs := OutputsFromMap(map[string]string{
+		"nginx":   "./docker/nginx/certs",
+		"haproxy": "/etc/haproxy/certs",
+	}, "/project")
+
+	if len(outputs) != 2 {
+		t.Fatalf("len(outputs) = %d, want 2", len(outputs))
+	}
+	// Sorted by layout
+	if outputs[0].Layout != "haproxy" || outputs[0].Dir != "/etc/haproxy/certs" {
+		t.Errorf("outputs[0] = %+v, want haproxy at /etc/haproxy/certs", outputs[0])
+	}
+	if outputs[1].Layout != "nginx" || outputs[1].Dir != "/project/docker/nginx/certs" {
+		t.Errorf("outputs[1] = %+v, want nginx at /project/docker/nginx/certs", outputs[1])
+	}
+}
+
+func TestGenerateCert_WithLayouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	certDir := filepath.Join(tmpDir, "certs")
+	outDir := filepath.Join(tmpDir, "out")
+	domain := "myapp.test"
+
+	outputs := []Output{
+		{Layout: LayoutNginx, Dir: filepath.Join(outDir, "nginx")},
+		{Layout: LayoutHAProxy, Dir: filepath.Join(outDir, "haproxy")},
+		{Layout: LayoutApache, Dir: filepath.Join(outDir, "apache")},
+	}
+
+	if err := GenerateCert(domain, certDir, DefaultCertInfo(), outputs...); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+
+	certPEM, _ := os.ReadFile(filepath.Join(certDir, domain+".crt"))
+	keyPEM, _ := os.ReadFile(filepath.Join(certDir, domain+".key"))
+
+	expected := map[string][]byte{
+		filepath.Join(outDir, "nginx", domain, "fullchain.pem"): certPEM,
+		filepath.Join(outDir, "nginx", domain, "privkey.pem"):   keyPEM,
+		filepath.Join(outDir, "haproxy", domain+".pem"):         append(append([]byte{}, certPEM...), keyPEM...),
+		filepath.Join(outDir, "apache", domain+".crt"):          certPEM,
+		filepath.Join(outDir, "apache", domain+".key"):          keyPEM,
+		filepath.Join(outDir, "apache", domain+"-chain.crt"):    certPEM,
+	}
+
+	for path, want := range expected {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s content mismatch", path)
+		}
+	}
+}
+
+func TestWriteOutputs_ReusesExistingKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	certDir := filepath.Join(tmpDir, "certs")
+	domain := "myapp.test"
+
+	if err := GenerateCert(domain, certDir, DefaultCertInfo()); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+	keyPEM, _ := os.ReadFile(filepath.Join(certDir, domain+".key"))
+
+	// Adding a layout later must not regenerate the key
+	out := Output{Layout: LayoutNginx, Dir: filepath.Join(tmpDir, "nginx")}
+	if err := WriteOutputs(domain, certDir, []Output{out}); err != nil {
+		t.Fatalf("WriteOutputs() error = %v", err)
+	}
+
+	after, _ := os.ReadFile(filepath.Join(certDir, domain+".key"))
+	if !bytes.Equal(keyPEM, after) {
+		t.Error("WriteOutputs() should not regenerate the private key")
+	}
+	privkey, _ := os.ReadFile(filepath.Join(out.Dir, domain, "privkey.pem"))
+	if !bytes.Equal(keyPEM, privkey) {
+		t.Error("privkey.pem should match the existing key")
+	}
+}
+
+func TestWriteOutputs_MissingCert(t *testing.T) {
+	tmpDir := t.TempDir()
+	out := Output{Layout: LayoutNginx, Dir: filepath.Join(tmpDir, "nginx")}
+	if err := WriteOutputs("missing.test", tmpDir, []Output{out}); err == nil {
+		t.Error("WriteOutputs() should fail when the certificate does not exist")
+	}
+}
+
+func TestWriteOutputs_UnknownLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := GenerateCert("myapp.test", tmpDir, DefaultCertInfo()); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+	out := Output{Layout: "caddy", Dir: filepath.Join(tmpDir, "caddy")}
+	if err := WriteOutputs("myapp.test", tmpDir, []Output{out}); err == nil {
+		t.Error("WriteOutputs() should fail for unknown layout")
+	}
+}
+
+func TestWriteOutputs_JavaKeystore(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not available")
+	}
+
+	tmpDir := t.TempDir()
+	domain := "myapp.test"
+	if err := GenerateCert(domain, tmpDir, DefaultCertInfo()); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+
+	out := Output{Layout: LayoutJava, Dir: filepath.Join(tmpDir, "java")}
+	if err := WriteOutputs(domain, tmpDir, []Output{out}); err != nil {
+		t.Fatalf("WriteOutputs() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out.Dir, domain+".p12")); err != nil {
+		t.Errorf("expected keystore to exist: %v", err)
+	}
+}
+
+func TestRemoveCert_WithLayouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	certDir := filepath.Join(tmpDir, "certs")
+	outDir := filepath.Join(tmpDir, "out")
+
+	outputs := []Output{
+		{Layout: LayoutNginx, Dir: filepath.Join(outDir, "nginx")},
+		{Layout: LayoutHAProxy, Dir: filepath.Join(outDir, "haproxy")},
+		{Layout: LayoutApache, Dir: filepath.Join(outDir, "apache")},
+		// Never written: removing missing layouts is not an error
+		{Layout: LayoutJava, Dir: filepath.Join(outDir, "java")},
+	}
+	for _, domain := range []string{"kept.test", "dropped.test"} {
+		if err := GenerateCert(domain, certDir, DefaultCertInfo(), outputs[:3]...); err != nil {
+			t.Fatalf("GenerateCert(%s) error = %v", domain, err)
+		}
+	}
+
+	if err := RemoveCert("dropped.test", certDir, outputs...); err != nil {
+		t.Fatalf("RemoveCert() error = %v", err)
+	}
+
+	removed := []string{
+		filepath.Join(certDir, "dropped.test.crt"),
+		filepath.Join(outDir, "nginx", "dropped.test"),
+		filepath.Join(outDir, "haproxy", "dropped.test.pem"),
+		filepath.Join(outDir, "apache", "dropped.test.crt"),
+		filepath.Join(outDir, "apache", "dropped.test.key"),
+		filepath.Join(outDir, "apache", "dropped.test-chain.crt"),
+	}
+	for _, path := range removed {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", path)
+		}
+	}
+
+	kept := []string{
+		filepath.Join(certDir, "kept.test.crt"),
+		filepath.Join(outDir, "nginx", "kept.test", "fullchain.pem"),
+		filepath.Join(outDir, "haproxy", "kept.test.pem"),
+		filepath.Join(outDir, "apache", "kept.test.crt"),
+	}
+	for _, path := range kept {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", path, err)
+		}
+	}
+}
+
+func TestWriteOutputs_ContinuesAfterFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	domain := "myapp.test"
+	if err := GenerateCert(domain, tmpDir, DefaultCertInfo()); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+
+	outputs := []Output{
+		{Layout: "caddy", Dir: filepath.Join(tmpDir, "caddy")},
+		{Layout: LayoutNginx, Dir: filepath.Join(tmpDir, "nginx")},
+	}
+	if err := WriteOutputs(domain, tmpDir, outputs); err == nil {
+		t.Error("WriteOutputs() should report the failing layout")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "nginx", domain, "fullchain.pem")); err != nil {
+		t.Errorf("nginx layout should still be written: %v", err)
+	}
+}
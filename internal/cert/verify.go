@@ -0,0 +1,132 @@
+package cert
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const verifyTimeout = 5 * time.Second
+
+// VerifyResult holds the outcome of an HTTPS endpoint check
+type VerifyResult struct {
+	Domain     string
+	Addr       string
+	Leaf       *x509.Certificate
+	ChainValid bool
+	ChainError string
+	SANMatch   bool
+	SANs       []string
+	NotAfter   time.Time
+	Expired    bool
+	StatusCode int
+	Err        error // connection or TLS handshake failure
+}
+
+// OK reports whether every check passed
+func (r *VerifyResult) OK() bool {
+	return r.Err == nil && r.ChainValid && r.SANMatch && !r.Expired && r.StatusCode > 0 && r.StatusCode < 500
+}
+
+// LoadRoots returns the system roots plus every bootapp certificate in certDir
+// bootapp certificates are self-signed, so each one is its own root
+func LoadRoots(certDir string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	domains, err := ListCerts(certDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, domain := range domains {
+		data, err := os.ReadFile(filepath.Join(certDir, domain+".crt"))
+		if err != nil {
+			continue
+		}
+		pool.AppendCertsFromPEM(data)
+	}
+	return pool, nil
+}
+
+// VerifyEndpoint opens a TLS connection to addr (ip:port) using domain as SNI,
+// validates the served certificate and issues a HEAD request for the status code
+func VerifyEndpoint(domain, addr string, roots *x509.CertPool) *VerifyResult {
+	result := &VerifyResult{Domain: domain, Addr: addr}
+
+	dialer := &net.Dialer{Timeout: verifyTimeout}
+	// Verification is done manually below so every problem is reported, not just the first
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName:         domain,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+
+	peers := conn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		result.Err = fmt.Errorf("server sent no certificate")
+		return result
+	}
+	leaf := peers[0]
+	result.Leaf = leaf
+
+	intermediates := x509.NewCertPool()
+	for _, c := range peers[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	}); err != nil {
+		result.ChainError = err.Error()
+	} else {
+		result.ChainValid = true
+	}
+
+	result.SANs = leaf.DNSNames
+	result.SANMatch = leaf.VerifyHostname(domain) == nil
+	result.NotAfter = leaf.NotAfter
+	result.Expired = time.Now().After(leaf.NotAfter)
+
+	conn.SetDeadline(time.Now().Add(verifyTimeout))
+	req, _ := http.NewRequest(http.MethodHead, "https://"+domain+"/", nil)
+	req.Close = true
+	if err := req.Write(conn); err != nil {
+		result.Err = fmt.Errorf("HTTP request failed: %w", err)
+		return result
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		result.Err = fmt.Errorf("HTTP response failed: %w", err)
+		return result
+	}
+	resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+
+	return result
+}
+
+// LoadCertificate reads the certificate for a domain from certDir
+func LoadCertificate(domain, certDir string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(filepath.Join(certDir, domain+".crt"))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM in %s.crt", domain)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,101 @@
+package cert
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// startTLSServer serves HTTPS with the bootapp certificate for domain
+func startTLSServer(t *testing.T, domain, certDir string) *httptest.Server {
+	t.Helper()
+
+	if err := GenerateCert(domain, certDir, DefaultCertInfo()); err != nil {
+		t.Fatalf("GenerateCert() error = %v", err)
+	}
+	pair, err := tls.LoadX509KeyPair(filepath.Join(certDir, domain+".crt"), filepath.Join(certDir, domain+".key"))
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair() error = %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{pair}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerifyEndpoint(t *testing.T) {
+	certDir := t.TempDir()
+	server := startTLSServer(t, "myapp.test", certDir)
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	roots, err := LoadRoots(certDir)
+	if err != nil {
+		t.Fatalf("LoadRoots() error = %v", err)
+	}
+
+	result := VerifyEndpoint("myapp.test", addr, roots)
+	if result.Err != nil {
+		t.Fatalf("VerifyEndpoint() error = %v", result.Err)
+	}
+	if !result.ChainValid {
+		t.Errorf("ChainValid = false, chain error: %s", result.ChainError)
+	}
+	if !result.SANMatch {
+		t.Error("SANMatch = false, want true")
+	}
+	if result.Expired {
+		t.Error("Expired = true, want false")
+	}
+	if result.StatusCode != http.StatusNoContent {
+		t.Errorf("StatusCode = %d, want %d", result.StatusCode, http.StatusNoContent)
+	}
+	if !result.OK() {
+		t.Error("OK() = false, want true")
+	}
+}
+
+func TestVerifyEndpoint_SANMismatch(t *testing.T) {
+	certDir := t.TempDir()
+	server := startTLSServer(t, "myapp.test", certDir)
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	roots, _ := LoadRoots(certDir)
+	result := VerifyEndpoint("other.test", addr, roots)
+	if result.SANMatch {
+		t.Error("SANMatch = true, want false for wrong domain")
+	}
+	if result.OK() {
+		t.Error("OK() = true, want false")
+	}
+}
+
+func TestVerifyEndpoint_UntrustedChain(t *testing.T) {
+	certDir := t.TempDir()
+	server := startTLSServer(t, "myapp.test", certDir)
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	// Roots without the bootapp certificate
+	roots, _ := LoadRoots(t.TempDir())
+	result := VerifyEndpoint("myapp.test", addr, roots)
+	if result.ChainValid {
+		t.Error("ChainValid = true, want false without bootapp roots")
+	}
+	if result.ChainError == "" {
+		t.Error("ChainError should describe the failure")
+	}
+}
+
+func TestVerifyEndpoint_ConnectionRefused(t *testing.T) {
+	roots, _ := LoadRoots(t.TempDir())
+	result := VerifyEndpoint("myapp.test", "127.0.0.1:1", roots)
+	if result.Err == nil {
+		t.Error("VerifyEndpoint() should fail when nothing is listening")
+	}
+}
@@ -0,0 +1,99 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Service selections for x-bootapp.auto_domains.services
+const (
+	AutoDomainsAll       = "all"       // every service
+	AutoDomainsPublished = "published" // services that publish a port
+)
+
+// AutoDomains generates a domain for services that have none
+//
+//	x-bootapp:
+//	  auto_domains:
+//	    template: "{service}.{project}.test"
+//	    services: published
+//	    ssl: true
+type AutoDomains struct {
+	// Template may use {service} and {project}
+	Template string `yaml:"template"`
+	// Services is "all" (default) or "published"
+	Services string `yaml:"services"`
+	// SSL registers the generated domains as SSL domains
+	SSL bool `yaml:"ssl"`
+}
+
+// ApplyDomainTemplate gives each service without domains the domain from
+// x-bootapp.auto_domains, as if it was declared in x-bootapp.domains
+// Services opt out with enable: false or auto_domain: false
+func ApplyDomainTemplate(compose *ComposeFile, projectName string) error {
+	auto := compose.XBootapp.AutoDomains
+	if auto.Template == "" {
+		return nil
+	}
+	switch auto.Services {
+	case "", AutoDomainsAll, AutoDomainsPublished:
+	default:
+		return fmt.Errorf("x-bootapp.auto_domains.services: unknown value %q (use all or published)", auto.Services)
+	}
+
+	for name, service := range compose.Services {
+		ext := service.XBootapp
+		if ext.Disabled() || (ext.AutoDomain != nil && !*ext.AutoDomain) {
+			continue
+		}
+		if len(serviceDomains(service)) > 0 {
+			continue
+		}
+		if auto.Services == AutoDomainsPublished && !hasPublishedPort(service) {
+			continue
+		}
+
+		domain, err := ExpandDomainTemplate(auto.Template, name, projectName)
+		if err != nil {
+			return err
+		}
+		if auto.SSL {
+			ext.SSLDomains = StringList{domain}
+		} else {
+			ext.Domains = StringList{domain}
+		}
+		service.XBootapp = ext
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// ExpandDomainTemplate fills {service} and {project} in template
+// Names are lowercased and underscores become hyphens, so the result is a valid host name
+func ExpandDomainTemplate(template, service, project string) (string, error) {
+	domain := strings.NewReplacer(
+		"{service}", domainLabel(service),
+		"{project}", domainLabel(project),
+	).Replace(template)
+
+	if strings.ContainsAny(domain, "{}") {
+		return "", fmt.Errorf("x-bootapp.auto_domains.template %q: unknown placeholder (use {service} and {project})", template)
+	}
+	if reason := checkDomainName(domain); reason != "" {
+		return "", fmt.Errorf("x-bootapp.auto_domains.template %q gives invalid domain '%s': %s", template, domain, reason)
+	}
+	return domain, nil
+}
+
+func domainLabel(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+func hasPublishedPort(service Service) bool {
+	for _, port := range service.Ports {
+		if port.Published != "" {
+			return true
+		}
+	}
+	return false
+}
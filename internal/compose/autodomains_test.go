@@ -0,0 +1,108 @@
+package compose
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyDomainTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  auto_domains:
+    template: "{service}.{project}.test"
+services:
+  web:
+    image: nginx
+    ports: ["8080:80"]
+  api_server:
+    image: node
+  app:
+    environment:
+      DOMAIN: shop.test
+  db:
+    image: mysql
+    x-bootapp:
+      auto_domain: false
+  worker:
+    labels:
+      bootapp.enable: "false"
+`,
+	})
+	path := filepath.Join(tmpDir, "docker-compose.yml")
+
+	tests := []struct {
+		name     string
+		services string
+		ssl      bool
+		want     map[string][]string
+		wantSSL  map[string][]string
+	}{
+		{
+			name: "all services",
+			want: map[string][]string{
+				"web":        {"web.shop.test"},
+				"api_server": {"api-server.shop.test"},
+				"app":        {"shop.test"},
+			},
+			wantSSL: map[string][]string{},
+		},
+		{
+			name:     "published only with ssl",
+			services: AutoDomainsPublished,
+			ssl:      true,
+			want: map[string][]string{
+				"web": {"web.shop.test"},
+				"app": {"shop.test"},
+			},
+			wantSSL: map[string][]string{"web": {"web.shop.test"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compose, err := ParseComposeFile(path)
+			if err != nil {
+				t.Fatalf("ParseComposeFile() error = %v", err)
+			}
+			compose.XBootapp.AutoDomains.Services = tt.services
+			compose.XBootapp.AutoDomains.SSL = tt.ssl
+
+			if err := ApplyDomainTemplate(compose, "shop"); err != nil {
+				t.Fatalf("ApplyDomainTemplate() error = %v", err)
+			}
+			if got := ExtractServiceDomains(compose); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractServiceDomains() = %v, want %v", got, tt.want)
+			}
+			if got := ExtractServiceSSLDomains(compose); !reflect.DeepEqual(got, tt.wantSSL) {
+				t.Errorf("ExtractServiceSSLDomains() = %v, want %v", got, tt.wantSSL)
+			}
+		})
+	}
+}
+
+func TestExpandDomainTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"{service}.{project}.test", "my-api.shop.test", false},
+		{"{project}-{service}.test", "shop-my-api.test", false},
+		{"{name}.test", "", true},
+		{"{service}..test", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := ExpandDomainTemplate(tt.template, "My_API", "shop")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandDomainTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandDomainTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
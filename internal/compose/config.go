@@ -0,0 +1,172 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Parser modes for LoadProject
+const (
+	ParserAuto   = "auto"   // docker compose config, falling back to the YAML parser
+	ParserDocker = "docker" // docker compose config only
+	ParserYAML   = "yaml"   // built-in YAML parser only
+)
+
+// LoadProject builds the compose model for the given files
+// In docker mode the model comes from "docker compose config", so interpolation,
+// profiles, includes and merges match exactly what Compose runs
+// In auto mode a failing "docker compose config" falls back to the YAML parser;
+// the returned warning explains why
+// projectDir overrides the project directory (default: the first file's directory)
+func LoadProject(paths []string, projectDir, mode string) (*ComposeFile, string, error) {
+	switch mode {
+	case ParserYAML:
+		compose, err := ParseComposeFilesInDir(paths, projectDir)
+		return compose, "", err
+	case ParserDocker:
+		compose, err := ParseComposeConfig(paths, projectDir)
+		return compose, "", err
+	case ParserAuto, "":
+		if !ComposeCLIAvailable() {
+			compose, err := ParseComposeFilesInDir(paths, projectDir)
+			return compose, "", err
+		}
+		compose, err := ParseComposeConfig(paths, projectDir)
+		if err == nil {
+			return compose, "", nil
+		}
+		warning := fmt.Sprintf("%v; using the built-in YAML parser (use --parser docker to make this an error)", err)
+		compose, err = ParseComposeFilesInDir(paths, projectDir)
+		return compose, warning, err
+	default:
+		return nil, "", fmt.Errorf("unknown parser mode: %s (use auto, docker or yaml)", mode)
+	}
+}
+
+// ComposeCLIAvailable checks if "docker compose" can be run
+func ComposeCLIAvailable() bool {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return false
+	}
+	return exec.Command("docker", "compose", "version").Run() == nil
+}
+
+// ParseComposeConfig runs "docker compose config --format json" and builds
+// the model from its fully resolved output
+// projectDir is passed as --project-directory when set
+func ParseComposeConfig(paths []string, projectDir string) (*ComposeFile, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files specified")
+	}
+
+	args := []string{"compose"}
+	for _, path := range paths {
+		args = append(args, "-f", path)
+	}
+	if projectDir != "" {
+		args = append(args, "--project-directory", projectDir)
+	} else {
+		projectDir = filepath.Dir(paths[0])
+	}
+	// Load every profile; inactive services are filtered like in YAML mode
+	args = append(args, "--profile", "*", "config", "--format", "json")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Dir = projectDir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("docker compose config failed: %s", msg)
+	}
+
+	return parseConfigJSON(stdout.Bytes(), declaresProjectName(paths))
+}
+
+// declaresProjectName reports whether any of the compose files sets a
+// top-level name, which "docker compose config" otherwise fills in from the
+// directory
+func declaresProjectName(paths []string) bool {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var root map[string]yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			continue
+		}
+		if _, ok := root["name"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseConfigJSON converts "docker compose config" JSON output into a ComposeFile
+// The name Compose derived for the project is kept only when explicitName is
+// set, and network names generated from it are dropped, so both follow the
+// project name bootapp resolves
+func parseConfigJSON(data []byte, explicitName bool) (*ComposeFile, error) {
+	// JSON is valid YAML, so the same node-based decoding is used
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return &ComposeFile{}, nil
+	}
+	var compose ComposeFile
+	if err := root.Decode(&compose); err != nil {
+		return nil, err
+	}
+
+	// env_file is already merged into environment by Compose
+	for name, service := range compose.Services {
+		service.EnvFile = nil
+		compose.Services[name] = service
+	}
+	if err := applyExtensions(&compose); err != nil {
+		return nil, err
+	}
+
+	// Compose names every network <project>_<key> unless the file sets a name
+	for key, network := range compose.Networks {
+		if compose.Name != "" && network.Name == compose.Name+"_"+key && !network.IsExternal() {
+			network.Name = ""
+			compose.Networks[key] = network
+		}
+	}
+	if !explicitName {
+		compose.Name = ""
+	}
+
+	// Compose always adds the implicit default network; it is not a custom network
+	if network, ok := compose.Networks["default"]; ok && network.Driver == "" && !hasIPAMConfig(network) {
+		delete(compose.Networks, "default")
+	}
+	for name, service := range compose.Services {
+		if netMap, ok := service.Networks.(map[string]interface{}); ok && len(netMap) == 1 {
+			if config, ok := netMap["default"]; ok && config == nil {
+				service.Networks = nil
+				compose.Services[name] = service
+			}
+		}
+	}
+
+	return &compose, nil
+}
+
+func hasIPAMConfig(network Network) bool {
+	return network.IPAM != nil && len(network.IPAM.Config) > 0
+}
@@ -0,0 +1,248 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Trimmed output of "docker compose config --format json"
+const sampleConfigJSON = `{
+  "name": "shop",
+  "services": {
+    "app": {
+      "image": "nginx",
+      "environment": {
+        "DOMAIN": "shop.test",
+        "SSL_DOMAINS": "shop.test"
+      },
+      "env_file": [{"path": "/project/.env.app", "required": true}],
+      "networks": {"default": null},
+      "ports": [
+        {"mode": "ingress", "target": 80, "published": "8080", "protocol": "tcp"},
+        {"mode": "ingress", "host_ip": "127.0.0.1", "target": 53, "published": "5353", "protocol": "udp"},
+        {"mode": "ingress", "target": 9000, "protocol": "tcp"}
+      ],
+      "x-bootapp": {"certs": {"nginx": "./certs"}}
+    },
+    "db": {
+      "image": "mysql:8",
+      "networks": {"default": null}
+    }
+  },
+  "networks": {
+    "default": {"name": "shop_default", "ipam": {}}
+  }
+}`
+
+func TestParseConfigJSON(t *testing.T) {
+	compose, err := parseConfigJSON([]byte(sampleConfigJSON), true)
+	if err != nil {
+		t.Fatalf("parseConfigJSON() error = %v", err)
+	}
+
+	if compose.Name != "shop" {
+		t.Errorf("Name = %q, want %q", compose.Name, "shop")
+	}
+	if len(compose.Services) != 2 {
+		t.Errorf("Services count = %d, want 2", len(compose.Services))
+	}
+
+	app := compose.Services["app"]
+	expectedPorts := []string{"8080:80", "127.0.0.1:5353:53/udp", "9000"}
+	if len(app.Ports) != len(expectedPorts) {
+		t.Fatalf("Ports = %v, want %v", app.Ports, expectedPorts)
+	}
+	for i, port := range expectedPorts {
+		if app.Ports[i].String() != port {
+			t.Errorf("Ports[%d] = %q, want %q", i, app.Ports[i], port)
+		}
+	}
+	if app.EnvFile != nil {
+		t.Errorf("EnvFile = %v, want nil (already merged by Compose)", app.EnvFile)
+	}
+	if app.XBootapp.Certs["nginx"] != "./certs" {
+		t.Errorf("XBootapp.Certs = %v", app.XBootapp.Certs)
+	}
+
+	if domains := ExtractServiceDomains(compose)["app"]; len(domains) != 1 || domains[0] != "shop.test" {
+		t.Errorf("app domains = %v, want [shop.test]", domains)
+	}
+
+	// The implicit default network must not count as a custom network
+	if err := ValidateForBootapp(compose); err != nil {
+		t.Errorf("ValidateForBootapp() error = %v", err)
+	}
+}
+
+func TestParseConfigJSON_CustomNetworkKept(t *testing.T) {
+	data := `{"services": {"app": {"image": "nginx"}},
+  "networks": {"default": {"ipam": {"config": [{"subnet": "10.1.0.0/16"}]}}}}`
+
+	compose, err := parseConfigJSON([]byte(data), false)
+	if err != nil {
+		t.Fatalf("parseConfigJSON() error = %v", err)
+	}
+	if _, ok := compose.Networks["default"]; !ok {
+		t.Error("default network with custom IPAM should be kept")
+	}
+}
+
+func TestParseConfigJSON_DerivedName(t *testing.T) {
+	data := `{"name": "shop", "services": {"app": {"image": "nginx", "networks": {"backend": null, "shared": null}}},
+  "networks": {
+    "backend": {"name": "shop_backend"},
+    "shared": {"name": "shared-net"},
+    "proxy": {"name": "proxy", "external": true}
+  }}`
+
+	compose, err := parseConfigJSON([]byte(data), false)
+	if err != nil {
+		t.Fatalf("parseConfigJSON() error = %v", err)
+	}
+	if compose.Name != "" {
+		t.Errorf("Name = %q, want none for a name Compose derived from the directory", compose.Name)
+	}
+	expected := map[string]string{"backend": "", "shared": "shared-net", "proxy": "proxy"}
+	for key, name := range expected {
+		if got := compose.Networks[key].Name; got != name {
+			t.Errorf("networks.%s.name = %q, want %q", key, got, name)
+		}
+	}
+}
+
+// fakeComposeConfig puts a docker CLI on PATH whose "compose config" prints output
+func fakeComposeConfig(t *testing.T, output string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the docker CLI")
+	}
+	// PATH holds only the fake CLI, so the script sticks to shell builtins
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$2\" = version ]; then exit 0; fi\nprintf '%s\\n' '" + output + "'\n"
+	if err := os.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+}
+
+func TestLoadProject_DockerModeProjectName(t *testing.T) {
+	t.Setenv("COMPOSE_PROJECT_NAME", "")
+	os.Unsetenv("COMPOSE_PROJECT_NAME")
+	output := `{"name": "shop", "services": {"app": {"image": "nginx", "networks": {"backend": null}}},
+  "networks": {"backend": {"name": "shop_backend"}}}`
+	fakeComposeConfig(t, output)
+
+	tests := []struct {
+		name       string
+		file       string
+		wantName   string
+		wantSource string
+	}{
+		{"derived from the directory", "services:\n  app:\n    image: nginx\n", "shop", ProjectNameFromDirectory},
+		{"set in the file", "name: shop\nservices:\n  app:\n    image: nginx\n", "shop", "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "shop")
+			writeFiles(t, dir, map[string]string{"docker-compose.yml": tt.file})
+
+			compose, _, err := LoadProject([]string{filepath.Join(dir, "docker-compose.yml")}, "", ParserDocker)
+			if err != nil {
+				t.Fatalf("LoadProject() error = %v", err)
+			}
+			name, source, err := ResolveProjectNameWithSource("", dir, compose)
+			if err != nil {
+				t.Fatalf("ResolveProjectNameWithSource() error = %v", err)
+			}
+			if name != tt.wantName || source != tt.wantSource {
+				t.Errorf("ResolveProjectNameWithSource() = %q from %q, want %q from %q", name, source, tt.wantName, tt.wantSource)
+			}
+
+			// Networks follow the name bootapp runs the project under
+			networks := ProjectNetworks(compose, "shop-2")
+			if len(networks) != 1 || networks[0].DockerName != "shop-2_backend" {
+				t.Errorf("ProjectNetworks() = %+v, want backend as shop-2_backend", networks)
+			}
+		})
+	}
+}
+
+func TestLoadProject_YAMLMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    environment:\n      DOMAIN: app.test\n"), 0644)
+
+	compose, _, err := LoadProject([]string{composePath}, "", ParserYAML)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if domain := ExtractDomain(compose); domain != "app.test" {
+		t.Errorf("ExtractDomain() = %q, want %q", domain, "app.test")
+	}
+}
+
+func TestLoadProject_AutoFallsBackWithoutDocker(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    environment:\n      DOMAIN: app.test\n"), 0644)
+
+	// No docker binary on PATH
+	t.Setenv("PATH", t.TempDir())
+
+	compose, warning, err := LoadProject([]string{composePath}, "", ParserAuto)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if warning != "" {
+		t.Errorf("LoadProject() warning = %q, want none without the docker CLI", warning)
+	}
+	if domain := ExtractDomain(compose); domain != "app.test" {
+		t.Errorf("ExtractDomain() = %q, want %q", domain, "app.test")
+	}
+
+	if _, _, err := LoadProject([]string{composePath}, "", ParserDocker); err == nil {
+		t.Error("LoadProject(docker) should fail without the docker CLI")
+	}
+}
+
+func TestLoadProject_AutoFallsBackOnConfigFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the docker CLI")
+	}
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    environment:\n      DOMAIN: app.test\n"), 0644)
+
+	// A docker CLI whose "compose version" works but "compose config" fails
+	binDir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$2\" = version ]; then exit 0; fi\necho 'unknown flag: --profile' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(binDir, "docker"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+
+	compose, warning, err := LoadProject([]string{composePath}, "", ParserAuto)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	if !strings.Contains(warning, "unknown flag: --profile") {
+		t.Errorf("LoadProject() warning = %q, want the docker compose config error", warning)
+	}
+	if domain := ExtractDomain(compose); domain != "app.test" {
+		t.Errorf("ExtractDomain() = %q, want %q", domain, "app.test")
+	}
+
+	if _, _, err := LoadProject([]string{composePath}, "", ParserDocker); err == nil {
+		t.Error("LoadProject(docker) should fail when docker compose config fails")
+	}
+}
+
+func TestLoadProject_UnknownMode(t *testing.T) {
+	if _, _, err := LoadProject([]string{"docker-compose.yml"}, "", "xml"); err == nil {
+		t.Error("LoadProject() should fail for unknown mode")
+	}
+}
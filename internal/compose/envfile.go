@@ -0,0 +1,131 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EnvFileRef is a single env_file entry
+type EnvFileRef struct {
+	Path     string
+	Required bool
+}
+
+// parseEnvFileRefs normalizes the env_file forms Compose accepts:
+//
+//	env_file: .env.app
+//	env_file: [.env.app, .env.local]
+//	env_file:
+//	  - path: .env.local
+//	    required: false
+func parseEnvFileRefs(envFile interface{}) ([]EnvFileRef, error) {
+	var refs []EnvFileRef
+
+	switch e := envFile.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		refs = append(refs, EnvFileRef{Path: e, Required: true})
+	case []interface{}:
+		for _, item := range e {
+			switch v := item.(type) {
+			case string:
+				refs = append(refs, EnvFileRef{Path: v, Required: true})
+			case map[string]interface{}:
+				path, _ := v["path"].(string)
+				if path == "" {
+					return nil, fmt.Errorf("env_file entry is missing 'path'")
+				}
+				ref := EnvFileRef{Path: path, Required: true}
+				if required, ok := v["required"].(bool); ok {
+					ref.Required = required
+				}
+				refs = append(refs, ref)
+			default:
+				return nil, fmt.Errorf("invalid env_file entry: %v", item)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid env_file: %v", envFile)
+	}
+
+	return refs, nil
+}
+
+// resolveEnvFiles loads each service's env_file entries relative to baseDir
+// and merges them with the inline environment in Compose precedence order:
+// later env files override earlier ones, inline environment overrides all
+func resolveEnvFiles(compose *ComposeFile, baseDir string, env map[string]string) error {
+	for name, service := range compose.Services {
+		refs, err := parseEnvFileRefs(service.EnvFile)
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+		if len(refs) == 0 {
+			continue
+		}
+
+		merged := make(map[string]interface{})
+		for _, ref := range refs {
+			path := ref.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			values, err := LoadDotEnv(path)
+			if err != nil {
+				if os.IsNotExist(err) && !ref.Required {
+					continue
+				}
+				return fmt.Errorf("service '%s': env file %s: %w", name, path, err)
+			}
+			for k, v := range values {
+				merged[k] = v
+			}
+		}
+
+		for k, v := range environmentMap(service.Environment, env) {
+			merged[k] = v
+		}
+
+		// env_file is consumed once merged so it is not applied twice
+		service.Environment = merged
+		service.EnvFile = nil
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// environmentMap converts an inline environment (list or map) into a map
+// Keys without a value are taken from env and dropped if unset, as in Compose
+func environmentMap(environment interface{}, env map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	switch e := environment.(type) {
+	case []interface{}:
+		for _, item := range e {
+			str, ok := item.(string)
+			if !ok {
+				continue
+			}
+			if key, value, found := strings.Cut(str, "="); found {
+				result[key] = value
+			} else if value, ok := env[str]; ok {
+				result[str] = value
+			}
+		}
+	case map[string]interface{}:
+		for key, value := range e {
+			if value == nil {
+				if v, ok := env[key]; ok {
+					result[key] = v
+				}
+				continue
+			}
+			result[key] = value
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,127 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEnvFileRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected []EnvFileRef
+	}{
+		{"nil", nil, nil},
+		{"string", ".env.app", []EnvFileRef{{".env.app", true}}},
+		{"list", []interface{}{".env.app", ".env.local"}, []EnvFileRef{{".env.app", true}, {".env.local", true}}},
+		{"long syntax", []interface{}{
+			map[string]interface{}{"path": ".env.local", "required": false},
+		}, []EnvFileRef{{".env.local", false}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseEnvFileRefs(tt.input)
+			if err != nil {
+				t.Fatalf("parseEnvFileRefs() error = %v", err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseEnvFileRefs() = %v, want %v", result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("parseEnvFileRefs()[%d] = %v, want %v", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseEnvFileRefs_Invalid(t *testing.T) {
+	if _, err := parseEnvFileRefs([]interface{}{map[string]interface{}{"required": false}}); err == nil {
+		t.Error("parseEnvFileRefs() should fail when path is missing")
+	}
+	if _, err := parseEnvFileRefs(42); err == nil {
+		t.Error("parseEnvFileRefs() should fail for non-string value")
+	}
+}
+
+func TestParseComposeFile_EnvFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	content := `services:
+  app:
+    image: nginx
+    env_file:
+      - .env.app
+      - path: .env.local
+        required: false
+      - path: .env.override
+        required: false
+    environment:
+      - APP_DOMAIN=inline.test
+  api:
+    image: nginx
+    env_file: config/api.env
+`
+	os.WriteFile(composePath, []byte(content), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".env.app"), []byte("DOMAIN=app.test\nSSL_DOMAINS=app.test\nAPP_DOMAIN=file.test\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".env.override"), []byte("DOMAIN=override.test\n"), 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "config"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "config", "api.env"), []byte("VIRTUAL_HOST=api.test\n"), 0644)
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+
+	// Later env file overrides earlier, inline overrides env files
+	app := serviceDomains["app"]
+	expected := map[string]bool{"override.test": true, "app.test": true, "inline.test": true}
+	if len(app) != len(expected) {
+		t.Errorf("app domains = %v, want %v", app, expected)
+	}
+	for _, d := range app {
+		if !expected[d] {
+			t.Errorf("unexpected app domain %q", d)
+		}
+	}
+
+	if api := serviceDomains["api"]; len(api) != 1 || api[0] != "api.test" {
+		t.Errorf("api domains = %v, want [api.test]", api)
+	}
+
+	if ssl := ExtractSSLDomains(compose); len(ssl) != 1 || ssl[0] != "app.test" {
+		t.Errorf("SSL domains = %v, want [app.test]", ssl)
+	}
+}
+
+func TestParseComposeFile_EnvFileMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    env_file: .env.missing\n"), 0644)
+
+	if _, err := ParseComposeFile(composePath); err == nil {
+		t.Error("ParseComposeFile() should fail for missing required env_file")
+	}
+}
+
+func TestEnvironmentMap(t *testing.T) {
+	env := map[string]string{"FROM_SHELL": "shell.test"}
+
+	result := environmentMap([]interface{}{"DOMAIN=app.test", "FROM_SHELL", "UNSET"}, env)
+	if result["DOMAIN"] != "app.test" || result["FROM_SHELL"] != "shell.test" {
+		t.Errorf("environmentMap(list) = %v", result)
+	}
+	if _, ok := result["UNSET"]; ok {
+		t.Error("unset key without value should be dropped")
+	}
+
+	result = environmentMap(map[string]interface{}{"DOMAIN": "app.test", "FROM_SHELL": nil}, env)
+	if result["DOMAIN"] != "app.test" || result["FROM_SHELL"] != "shell.test" {
+		t.Errorf("environmentMap(map) = %v", result)
+	}
+}
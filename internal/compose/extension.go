@@ -0,0 +1,306 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabelPrefix is the prefix for bootapp service labels
+const LabelPrefix = "bootapp."
+
+// ProjectExtension represents the top-level x-bootapp block
+//
+//	x-bootapp:
+//	  subnet: 172.25.0.0/16
+//	  network: frontend
+//	  hostnames: true
+//	  instance: worktree
+//	  auto_domains:
+//	    template: "{service}.{project}.test"
+//	  hooks:
+//	    post_up: ./scripts/seed.sh
+//	  services:
+//	    app:
+//	      domains: [app.test]
+type ProjectExtension struct {
+	// Subnet pins the project subnet instead of allocating one
+	Subnet string `yaml:"subnet"`
+	// Network is the network whose container IPs are registered for domains
+	Network string `yaml:"network"`
+	// IPv6 gives the routable network a /64 from the machine's ULA prefix
+	IPv6 *bool `yaml:"ipv6"`
+	// Hostnames turns hostname/domainname/container_name into domains for every service
+	Hostnames *bool `yaml:"hostnames"`
+	// Instance runs each git worktree or branch as its own project:
+	// "worktree" or "branch" (see DetectInstance)
+	Instance    string                      `yaml:"instance"`
+	AutoDomains AutoDomains                 `yaml:"auto_domains"`
+	Hooks       Hooks                       `yaml:"hooks"`
+	Services    map[string]ServiceExtension `yaml:"services"`
+}
+
+// Hooks are shell commands run from the project directory around up and down
+type Hooks struct {
+	PreUp    StringList `yaml:"pre_up"`
+	PostUp   StringList `yaml:"post_up"`
+	PreDown  StringList `yaml:"pre_down"`
+	PostDown StringList `yaml:"post_down"`
+}
+
+// ServiceExtension represents the per-service bootapp settings, declared in the
+// service's x-bootapp block, the top-level x-bootapp.services block or bootapp.* labels
+type ServiceExtension struct {
+	// Enable set to false opts the service out of domains, certificates and hosts entries
+	Enable *bool `yaml:"enable"`
+	// Domains and SSLDomains replace the DOMAIN/SSL_DOMAINS/... heuristics when set
+	Domains    StringList `yaml:"domains"`
+	SSLDomains StringList `yaml:"ssl_domains"`
+	// Certs maps an output layout (nginx, haproxy, apache, java) to a directory
+	// relative to the project root, e.g. nginx: ./docker/nginx/certs
+	Certs map[string]string `yaml:"certs"`
+	// Hostnames turns hostname/domainname/container_name into a domain;
+	// unset follows x-bootapp.hostnames
+	Hostnames *bool `yaml:"hostnames"`
+	// AutoDomain set to false opts the service out of x-bootapp.auto_domains
+	AutoDomain *bool `yaml:"auto_domain"`
+}
+
+// StringList accepts a single string or a list of strings
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "" {
+			*l = StringList{node.Value}
+		}
+		return nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+	return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+}
+
+// Disabled reports whether the service opted out of bootapp
+func (e ServiceExtension) Disabled() bool {
+	return e.Enable != nil && !*e.Enable
+}
+
+// HostnamesEnabled reports whether the compose naming fields become a domain
+func (e ServiceExtension) HostnamesEnabled() bool {
+	return e.Hostnames != nil && *e.Hostnames
+}
+
+// IPv6Enabled reports whether the routable network gets an IPv6 subnet
+func (e ProjectExtension) IPv6Enabled() bool {
+	return e.IPv6 != nil && *e.IPv6
+}
+
+// HostnamesEnabled reports whether the compose naming fields become domains
+// for services that do not set hostnames themselves
+func (e ProjectExtension) HostnamesEnabled() bool {
+	return e.Hostnames != nil && *e.Hostnames
+}
+
+// Declared reports whether domains are declared explicitly, which takes
+// precedence over the environment and reverse-proxy label heuristics
+func (e ServiceExtension) Declared() bool {
+	return len(e.Domains) > 0 || len(e.SSLDomains) > 0
+}
+
+// mergeServiceExtension overlays override on base
+// Domain lists and enable are replaced, certs are merged by layout
+func mergeServiceExtension(base, override ServiceExtension) ServiceExtension {
+	if override.Enable != nil {
+		base.Enable = override.Enable
+	}
+	if override.Hostnames != nil {
+		base.Hostnames = override.Hostnames
+	}
+	if override.AutoDomain != nil {
+		base.AutoDomain = override.AutoDomain
+	}
+	if len(override.Domains) > 0 {
+		base.Domains = override.Domains
+	}
+	if len(override.SSLDomains) > 0 {
+		base.SSLDomains = override.SSLDomains
+	}
+
+	if len(override.Certs) > 0 {
+		certs := make(map[string]string)
+		for layout, dir := range base.Certs {
+			certs[layout] = dir
+		}
+		for layout, dir := range override.Certs {
+			certs[layout] = dir
+		}
+		base.Certs = certs
+	}
+	return base
+}
+
+// mergeProjectExtension overlays override on base
+func mergeProjectExtension(base, override ProjectExtension) ProjectExtension {
+	if override.Subnet != "" {
+		base.Subnet = override.Subnet
+	}
+	if override.Network != "" {
+		base.Network = override.Network
+	}
+	if override.IPv6 != nil {
+		base.IPv6 = override.IPv6
+	}
+	if override.Hostnames != nil {
+		base.Hostnames = override.Hostnames
+	}
+	if override.Instance != "" {
+		base.Instance = override.Instance
+	}
+	if override.AutoDomains.Template != "" {
+		base.AutoDomains.Template = override.AutoDomains.Template
+	}
+	if override.AutoDomains.Services != "" {
+		base.AutoDomains.Services = override.AutoDomains.Services
+	}
+	if override.AutoDomains.SSL {
+		base.AutoDomains.SSL = true
+	}
+	if len(override.Hooks.PreUp) > 0 {
+		base.Hooks.PreUp = override.Hooks.PreUp
+	}
+	if len(override.Hooks.PostUp) > 0 {
+		base.Hooks.PostUp = override.Hooks.PostUp
+	}
+	if len(override.Hooks.PreDown) > 0 {
+		base.Hooks.PreDown = override.Hooks.PreDown
+	}
+	if len(override.Hooks.PostDown) > 0 {
+		base.Hooks.PostDown = override.Hooks.PostDown
+	}
+
+	if len(override.Services) > 0 && base.Services == nil {
+		base.Services = make(map[string]ServiceExtension)
+	}
+	for name, ext := range override.Services {
+		base.Services[name] = mergeServiceExtension(base.Services[name], ext)
+	}
+	return base
+}
+
+// applyExtensions folds x-bootapp.services entries and bootapp.* labels into
+// each service's XBootapp, so extraction only has to look in one place
+// Precedence (lowest first): x-bootapp.services, service x-bootapp, labels
+func applyExtensions(compose *ComposeFile) error {
+	names := make([]string, 0, len(compose.XBootapp.Services))
+	for name := range compose.XBootapp.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := compose.Services[name]; !ok {
+			return fmt.Errorf("x-bootapp.services: service '%s' is not defined", name)
+		}
+	}
+
+	for name, service := range compose.Services {
+		// Unknown labels are left to "bootapp lint", so a typo or a label from a
+		// newer version never keeps a project from coming up or down
+		labels, _, err := parseBootappLabels(service.Labels)
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+
+		ext := mergeServiceExtension(compose.XBootapp.Services[name], service.XBootapp)
+		ext = mergeServiceExtension(ext, labels)
+		ext.Domains = splitDomainList(ext.Domains)
+		ext.SSLDomains = splitDomainList(ext.SSLDomains)
+		if ext.Hostnames == nil {
+			ext.Hostnames = compose.XBootapp.Hostnames
+		}
+
+		service.XBootapp = ext
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// parseBootappLabels reads the bootapp.* service labels:
+//
+//	bootapp.enable=false
+//	bootapp.hostnames=true
+//	bootapp.auto_domain=false
+//	bootapp.domains=app.test,api.test
+//	bootapp.ssl_domains=app.test
+//	bootapp.certs.nginx=./docker/nginx/certs
+//
+// Unknown bootapp.* labels are skipped and returned, sorted
+func parseBootappLabels(labels interface{}) (ServiceExtension, []string, error) {
+	var ext ServiceExtension
+	var unknown []string
+
+	for key, value := range toMapping(labels) {
+		if !strings.HasPrefix(key, LabelPrefix) {
+			continue
+		}
+		str := fmt.Sprint(value)
+		if value == nil {
+			str = ""
+		}
+
+		name := strings.TrimPrefix(key, LabelPrefix)
+		switch {
+		case name == "enable":
+			enable, err := strconv.ParseBool(str)
+			if err != nil {
+				return ext, nil, fmt.Errorf("label %s: invalid boolean %q", key, str)
+			}
+			ext.Enable = &enable
+		case name == "hostnames":
+			hostnames, err := strconv.ParseBool(str)
+			if err != nil {
+				return ext, nil, fmt.Errorf("label %s: invalid boolean %q", key, str)
+			}
+			ext.Hostnames = &hostnames
+		case name == "auto_domain":
+			autoDomain, err := strconv.ParseBool(str)
+			if err != nil {
+				return ext, nil, fmt.Errorf("label %s: invalid boolean %q", key, str)
+			}
+			ext.AutoDomain = &autoDomain
+		case name == "domains":
+			ext.Domains = splitDomains(str)
+		case name == "ssl_domains":
+			ext.SSLDomains = splitDomains(str)
+		case strings.HasPrefix(name, "certs."):
+			if ext.Certs == nil {
+				ext.Certs = make(map[string]string)
+			}
+			ext.Certs[strings.TrimPrefix(name, "certs.")] = str
+		default:
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(unknown)
+	return ext, unknown, nil
+}
+
+// splitDomainList splits comma or space separated entries of a domain list
+func splitDomainList(list StringList) StringList {
+	var result StringList
+	for _, item := range list {
+		result = append(result, splitDomains(item)...)
+	}
+	return result
+}
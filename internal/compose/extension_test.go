@@ -0,0 +1,234 @@
+package compose
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseComposeFile_XBootappBlock(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  subnet: 172.25.0.0/16
+  hooks:
+    pre_up: ./scripts/prepare.sh
+    post_up:
+      - ./scripts/migrate.sh
+      - ./scripts/seed.sh
+  services:
+    app:
+      domains: app.test, www.app.test
+      ssl_domains: [app.test]
+      certs:
+        nginx: ./certs
+services:
+  app:
+    environment:
+      DOMAIN: ignored.test
+      SSL_DOMAINS: ignored.test
+  worker:
+    environment:
+      DOMAIN: worker.test
+    x-bootapp:
+      enable: false
+  api:
+    environment:
+      VIRTUAL_HOST: api.test
+`,
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	if compose.XBootapp.Subnet != "172.25.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", compose.XBootapp.Subnet, "172.25.0.0/16")
+	}
+	if len(compose.XBootapp.Hooks.PreUp) != 1 || len(compose.XBootapp.Hooks.PostUp) != 2 {
+		t.Errorf("Hooks = %+v", compose.XBootapp.Hooks)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	app := serviceDomains["app"]
+	if len(app) != 2 || app[0] != "app.test" || app[1] != "www.app.test" {
+		t.Errorf("app domains = %v, want [app.test www.app.test] (declared domains win over env)", app)
+	}
+	if _, ok := serviceDomains["worker"]; ok {
+		t.Error("worker opted out and should have no domains")
+	}
+	if api := serviceDomains["api"]; len(api) != 1 || api[0] != "api.test" {
+		t.Errorf("api domains = %v, want [api.test] (heuristics still apply)", api)
+	}
+
+	if ssl := ExtractSSLDomains(compose); len(ssl) != 1 || ssl[0] != "app.test" {
+		t.Errorf("SSL domains = %v, want [app.test]", ssl)
+	}
+	if compose.Services["app"].XBootapp.Certs["nginx"] != "./certs" {
+		t.Errorf("app certs = %v", compose.Services["app"].XBootapp.Certs)
+	}
+}
+
+func TestParseComposeFile_BootappLabels(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `services:
+  app:
+    environment:
+      SSL_DOMAINS: ignored.test
+    x-bootapp:
+      domains: [block.test]
+    labels:
+      bootapp.domains: "app.test,admin.app.test"
+      bootapp.ssl_domains: app.test
+      bootapp.certs.haproxy: ./haproxy
+  db:
+    environment:
+      DOMAIN: db.test
+    labels:
+      - bootapp.enable=false
+`,
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	expected := []string{"app.test", "admin.app.test"}
+	app := serviceDomains["app"]
+	if len(app) != len(expected) {
+		t.Fatalf("app domains = %v, want %v (labels win over the service block)", app, expected)
+	}
+	for i, d := range expected {
+		if app[i] != d {
+			t.Errorf("app domains[%d] = %q, want %q", i, app[i], d)
+		}
+	}
+	if _, ok := serviceDomains["db"]; ok {
+		t.Error("db opted out via label and should have no domains")
+	}
+	if ssl := ExtractServiceSSLDomains(compose)["app"]; len(ssl) != 1 || ssl[0] != "app.test" {
+		t.Errorf("app SSL domains = %v, want [app.test]", ssl)
+	}
+	if compose.Services["app"].XBootapp.Certs["haproxy"] != "./haproxy" {
+		t.Errorf("app certs = %v", compose.Services["app"].XBootapp.Certs)
+	}
+}
+
+func TestParseComposeFile_XBootappOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  subnet: 172.25.0.0/16
+services:
+  app:
+    x-bootapp:
+      domains: [app.test]
+`,
+		"docker-compose.override.yml": `x-bootapp:
+  subnet: 172.26.0.0/16
+services:
+  app:
+    x-bootapp:
+      domains: [local.app.test]
+`,
+	})
+
+	compose, err := ParseComposeFiles([]string{
+		filepath.Join(tmpDir, "docker-compose.yml"),
+		filepath.Join(tmpDir, "docker-compose.override.yml"),
+	})
+	if err != nil {
+		t.Fatalf("ParseComposeFiles() error = %v", err)
+	}
+
+	if compose.XBootapp.Subnet != "172.26.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", compose.XBootapp.Subnet, "172.26.0.0/16")
+	}
+	if domain := ExtractDomain(compose); domain != "local.app.test" {
+		t.Errorf("ExtractDomain() = %q, want %q", domain, "local.app.test")
+	}
+}
+
+func TestParseComposeFile_XBootappOverrideDisables(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  ipv6: true
+  hostnames: true
+services:
+  api:
+    hostname: api
+    domainname: shop.test
+`,
+		"docker-compose.override.yml": `x-bootapp:
+  ipv6: false
+  hostnames: false
+`,
+	})
+
+	base, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+	if !base.XBootapp.IPv6Enabled() || !base.XBootapp.HostnamesEnabled() {
+		t.Errorf("base file: ipv6 = %v, hostnames = %v, want both enabled", base.XBootapp.IPv6Enabled(), base.XBootapp.HostnamesEnabled())
+	}
+
+	compose, err := ParseComposeFiles([]string{
+		filepath.Join(tmpDir, "docker-compose.yml"),
+		filepath.Join(tmpDir, "docker-compose.override.yml"),
+	})
+	if err != nil {
+		t.Fatalf("ParseComposeFiles() error = %v", err)
+	}
+	if compose.XBootapp.IPv6Enabled() || compose.XBootapp.HostnamesEnabled() {
+		t.Errorf("ipv6 = %v, hostnames = %v, want both disabled by the override", compose.XBootapp.IPv6Enabled(), compose.XBootapp.HostnamesEnabled())
+	}
+	if domains := ExtractServiceDomains(compose); len(domains) != 0 {
+		t.Errorf("ExtractServiceDomains() = %v, want none with hostnames disabled", domains)
+	}
+}
+
+func TestParseComposeFile_UnknownLabelIgnored(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `services:
+  app:
+    labels:
+      bootapp.domain: typo.test
+      bootapp.domains: app.test
+      bootapp.future_option: "true"
+`,
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v, want unknown labels ignored", err)
+	}
+	if domains := ExtractServiceDomains(compose)["app"]; len(domains) != 1 || domains[0] != "app.test" {
+		t.Errorf("app domains = %v, want [app.test]", domains)
+	}
+}
+
+func TestParseComposeFile_XBootappErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown service", "x-bootapp:\n  services:\n    missing:\n      enable: false\nservices:\n  app:\n    image: nginx\n"},
+		{"invalid enable label", "services:\n  app:\n    labels:\n      bootapp.enable: maybe\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeFiles(t, tmpDir, map[string]string{"docker-compose.yml": tt.content})
+			if _, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml")); err == nil {
+				t.Error("ParseComposeFile() should fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package compose
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeRef is a single top-level include entry
+type IncludeRef struct {
+	Paths            []string
+	ProjectDirectory string
+	EnvFiles         []string
+}
+
+// ExtendsRef is a service-level extends entry
+type ExtendsRef struct {
+	File    string // empty means the same file
+	Service string
+}
+
+// loadFile parses one compose file and resolves its extends and include entries
+// projectDir is the directory relative env_file paths are resolved against
+// stack holds the files currently being loaded, for include cycle detection
+func loadFile(path string, env map[string]string, projectDir string, stack []string) (*ComposeFile, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("include cycle detected: %s", strings.Join(append(stack, absPath), " -> "))
+		}
+	}
+	stack = append(stack, absPath)
+
+	compose, err := parseSingleFile(absPath, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveExtends(compose, absPath, env); err != nil {
+		return nil, err
+	}
+	for name, service := range compose.Services {
+		service.EnvFile = absoluteEnvFiles(service.EnvFile, projectDir)
+		compose.Services[name] = service
+	}
+
+	if err := resolveIncludes(compose, absPath, stack); err != nil {
+		return nil, err
+	}
+
+	return compose, nil
+}
+
+// parseIncludeRefs normalizes the include forms Compose accepts:
+//
+//	include:
+//	  - ./db/compose.yaml
+//	  - path: [./api/compose.yaml, ./api/compose.override.yaml]
+//	    project_directory: ./api
+//	    env_file: ./api/.env
+func parseIncludeRefs(include interface{}) ([]IncludeRef, error) {
+	list, ok := include.([]interface{})
+	if !ok {
+		if include == nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("include must be a list")
+	}
+
+	var refs []IncludeRef
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			refs = append(refs, IncludeRef{Paths: []string{v}})
+		case map[string]interface{}:
+			ref := IncludeRef{
+				Paths:    stringList(v["path"]),
+				EnvFiles: stringList(v["env_file"]),
+			}
+			ref.ProjectDirectory, _ = v["project_directory"].(string)
+			if len(ref.Paths) == 0 {
+				return nil, fmt.Errorf("include entry is missing 'path'")
+			}
+			refs = append(refs, ref)
+		default:
+			return nil, fmt.Errorf("invalid include entry: %v", item)
+		}
+	}
+	return refs, nil
+}
+
+// resolveIncludes loads included files and adds their services and networks
+// Each include is its own sub-project: paths are relative to the including file,
+// and its variables come from its env_file (default: .env in its project directory)
+func resolveIncludes(compose *ComposeFile, path string, stack []string) error {
+	refs, err := parseIncludeRefs(compose.Include)
+	if err != nil {
+		return err
+	}
+	compose.Include = nil
+
+	dir := filepath.Dir(path)
+	for _, ref := range refs {
+		paths, projectDir, env, err := includeProject(ref, dir)
+		if err != nil {
+			return fmt.Errorf("include %s: %w", ref.Paths[0], err)
+		}
+
+		included, err := loadFiles(paths, env, projectDir, stack)
+		if err != nil {
+			return fmt.Errorf("include %s: %w", ref.Paths[0], err)
+		}
+		// Resolve env_file now, with the included project's variables
+		if err := resolveEnvFiles(included, projectDir, env); err != nil {
+			return fmt.Errorf("include %s: %w", ref.Paths[0], err)
+		}
+		// The included x-bootapp block applies to the included services only
+		if err := applyExtensions(included); err != nil {
+			return fmt.Errorf("include %s: %w", ref.Paths[0], err)
+		}
+
+		if len(included.Services) > 0 && compose.Services == nil {
+			compose.Services = make(map[string]Service)
+		}
+		for name, service := range included.Services {
+			if _, exists := compose.Services[name]; exists {
+				return fmt.Errorf("service '%s' from include %s conflicts with an existing service", name, ref.Paths[0])
+			}
+			compose.Services[name] = service
+		}
+
+		if len(included.Networks) > 0 && compose.Networks == nil {
+			compose.Networks = make(map[string]Network)
+		}
+		for name, network := range included.Networks {
+			if _, exists := compose.Networks[name]; !exists {
+				compose.Networks[name] = network
+			}
+		}
+	}
+	return nil
+}
+
+// includeProject returns the files, project directory and variables of an
+// include entry in a file in dir
+func includeProject(ref IncludeRef, dir string) ([]string, string, map[string]string, error) {
+	paths := make([]string, len(ref.Paths))
+	for i, p := range ref.Paths {
+		paths[i] = resolvePath(dir, p)
+	}
+
+	projectDir := filepath.Dir(paths[0])
+	if ref.ProjectDirectory != "" {
+		projectDir = resolvePath(dir, ref.ProjectDirectory)
+	}
+
+	envFiles := []string{filepath.Join(projectDir, ".env")}
+	required := false
+	if len(ref.EnvFiles) > 0 {
+		envFiles = nil
+		for _, f := range ref.EnvFiles {
+			envFiles = append(envFiles, resolvePath(dir, f))
+		}
+		required = true
+	}
+	env, err := loadEnv(envFiles, required)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return paths, projectDir, env, nil
+}
+
+// parseExtendsRef normalizes "extends: base" and "extends: {file, service}"
+func parseExtendsRef(extends interface{}) (ExtendsRef, error) {
+	switch v := extends.(type) {
+	case string:
+		return ExtendsRef{Service: v}, nil
+	case map[string]interface{}:
+		ref := ExtendsRef{}
+		ref.File, _ = v["file"].(string)
+		ref.Service, _ = v["service"].(string)
+		if ref.Service == "" {
+			return ref, fmt.Errorf("extends is missing 'service'")
+		}
+		return ref, nil
+	default:
+		return ExtendsRef{}, fmt.Errorf("invalid extends: %v", extends)
+	}
+}
+
+// resolveExtends replaces every service that uses extends with the merged result
+func resolveExtends(compose *ComposeFile, path string, env map[string]string) error {
+	resolved := make(map[string]Service)
+	for name := range compose.Services {
+		service, err := resolveService(compose, name, path, env, nil)
+		if err != nil {
+			return fmt.Errorf("service '%s': %w", name, err)
+		}
+		resolved[name] = service
+	}
+	for name, service := range resolved {
+		compose.Services[name] = service
+	}
+	return nil
+}
+
+// resolveService returns the named service with its extends chain applied
+// chain holds "file#service" keys already visited, for cycle detection
+func resolveService(compose *ComposeFile, name, path string, env map[string]string, chain []string) (Service, error) {
+	service, ok := compose.Services[name]
+	if !ok {
+		return Service{}, fmt.Errorf("service '%s' not found in %s", name, filepath.Base(path))
+	}
+
+	key := path + "#" + name
+	for _, k := range chain {
+		if k == key {
+			return Service{}, fmt.Errorf("extends cycle detected: %s", strings.Join(append(chain, key), " -> "))
+		}
+	}
+	chain = append(chain, key)
+
+	if service.Extends == nil {
+		return service, nil
+	}
+	ref, err := parseExtendsRef(service.Extends)
+	if err != nil {
+		return Service{}, err
+	}
+
+	baseCompose, basePath := compose, path
+	if ref.File != "" {
+		basePath = resolvePath(filepath.Dir(path), ref.File)
+		if baseCompose, err = parseSingleFile(basePath, env); err != nil {
+			return Service{}, fmt.Errorf("extends %s: %w", ref.File, err)
+		}
+	}
+
+	base, err := resolveService(baseCompose, ref.Service, basePath, env, chain)
+	if err != nil {
+		return Service{}, err
+	}
+	if basePath != path {
+		// Relative paths in the base service are relative to its own file
+		base.EnvFile = absoluteEnvFiles(base.EnvFile, filepath.Dir(basePath))
+	}
+
+	service.Extends = nil
+	return mergeService(base, service), nil
+}
+
+// absoluteEnvFiles resolves relative env_file paths against baseDir
+func absoluteEnvFiles(envFile interface{}, baseDir string) interface{} {
+	switch e := envFile.(type) {
+	case string:
+		return resolvePath(baseDir, e)
+	case []interface{}:
+		result := make([]interface{}, len(e))
+		for i, item := range e {
+			switch v := item.(type) {
+			case string:
+				result[i] = resolvePath(baseDir, v)
+			case map[string]interface{}:
+				entry := make(map[string]interface{})
+				for k, val := range v {
+					entry[k] = val
+				}
+				if p, ok := v["path"].(string); ok {
+					entry["path"] = resolvePath(baseDir, p)
+				}
+				result[i] = entry
+			default:
+				result[i] = item
+			}
+		}
+		return result
+	}
+	return envFile
+}
+
+func resolvePath(baseDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// stringList accepts a single string or a list of strings
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
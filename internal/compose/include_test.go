@@ -0,0 +1,188 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files relative to dir, creating parent directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestParseIncludeRefs(t *testing.T) {
+	refs, err := parseIncludeRefs([]interface{}{
+		"./db/compose.yaml",
+		map[string]interface{}{
+			"path":              []interface{}{"./api/compose.yaml", "./api/compose.dev.yaml"},
+			"project_directory": "./api",
+			"env_file":          "./api/.env",
+		},
+	})
+	if err != nil {
+		t.Fatalf("parseIncludeRefs() error = %v", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("len(refs) = %d, want 2", len(refs))
+	}
+	if len(refs[0].Paths) != 1 || refs[0].Paths[0] != "./db/compose.yaml" {
+		t.Errorf("refs[0] = %+v", refs[0])
+	}
+	if len(refs[1].Paths) != 2 || refs[1].ProjectDirectory != "./api" || len(refs[1].EnvFiles) != 1 {
+		t.Errorf("refs[1] = %+v", refs[1])
+	}
+
+	if _, err := parseIncludeRefs([]interface{}{map[string]interface{}{"env_file": ".env"}}); err == nil {
+		t.Error("parseIncludeRefs() should fail when path is missing")
+	}
+}
+
+func TestParseComposeFile_Include(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": `include:
+  - ./db/compose.yaml
+  - path: ./api/compose.yaml
+    env_file: ./api/vars.env
+services:
+  web:
+    environment:
+      DOMAIN: web.test
+`,
+		"db/compose.yaml": `services:
+  db:
+    env_file: db.env
+`,
+		"db/db.env":    "DOMAIN=db.test\n",
+		"db/.env":      "UNUSED=1\n",
+		"api/vars.env": "API_HOST=api.test\n",
+		"api/compose.yaml": `services:
+  api:
+    environment:
+      SSL_DOMAINS: ${API_HOST}
+`,
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	expected := map[string]string{"web": "web.test", "db": "db.test", "api": "api.test"}
+	for service, domain := range expected {
+		if domains := serviceDomains[service]; len(domains) != 1 || domains[0] != domain {
+			t.Errorf("%s domains = %v, want [%s]", service, domains, domain)
+		}
+	}
+
+	if ssl := ExtractSSLDomains(compose); len(ssl) != 1 || ssl[0] != "api.test" {
+		t.Errorf("SSL domains = %v, want [api.test]", ssl)
+	}
+}
+
+func TestParseComposeFile_IncludeConflict(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": "include:\n  - ./other.yaml\nservices:\n  web:\n    image: nginx\n",
+		"other.yaml":   "services:\n  web:\n    image: httpd\n",
+	})
+
+	if _, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml")); err == nil {
+		t.Error("ParseComposeFile() should fail when an included service conflicts")
+	}
+}
+
+func TestParseComposeFile_IncludeCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": "include:\n  - ./a.yaml\n",
+		"a.yaml":       "include:\n  - ./b.yaml\n",
+		"b.yaml":       "include:\n  - ./a.yaml\n",
+	})
+
+	_, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("ParseComposeFile() error = %v, want include cycle", err)
+	}
+}
+
+func TestParseComposeFile_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": `services:
+  base:
+    image: nginx
+    environment:
+      DOMAIN: base.test
+  web:
+    extends: base
+    environment:
+      SSL_DOMAINS: web.test
+  admin:
+    extends:
+      file: ./common/services.yaml
+      service: proxy
+`,
+		"common/services.yaml": `services:
+  proxy:
+    image: traefik
+    env_file: proxy.env
+    labels:
+      - "traefik.http.routers.admin.rule=Host(` + "`admin.test`" + `)"
+`,
+		"common/proxy.env": "VIRTUAL_HOST=proxy.test\n",
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	if compose.Services["web"].Image != "nginx" {
+		t.Errorf("web image = %q, want inherited nginx", compose.Services["web"].Image)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	if web := serviceDomains["web"]; len(web) != 2 {
+		t.Errorf("web domains = %v, want base.test and web.test", web)
+	}
+	admin := serviceDomains["admin"]
+	if len(admin) != 2 || admin[0] != "proxy.test" || admin[1] != "admin.test" {
+		t.Errorf("admin domains = %v, want [proxy.test admin.test]", admin)
+	}
+}
+
+func TestParseComposeFile_ExtendsCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": "services:\n  a:\n    extends: b\n  b:\n    extends: a\n",
+	})
+
+	_, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml"))
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("ParseComposeFile() error = %v, want extends cycle", err)
+	}
+}
+
+func TestParseComposeFile_ExtendsMissingService(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"compose.yaml": "services:\n  web:\n    extends: missing\n",
+	})
+
+	if _, err := ParseComposeFile(filepath.Join(tmpDir, "compose.yaml")); err == nil {
+		t.Error("ParseComposeFile() should fail when the extended service does not exist")
+	}
+}
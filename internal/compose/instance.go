@@ -0,0 +1,276 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Modes for x-bootapp.instance
+const (
+	InstanceWorktree = "worktree" // each linked git worktree is an instance
+	InstanceBranch   = "branch"   // each checked-out branch is an instance
+)
+
+// defaultBranches never get an instance in branch mode, besides the branch
+// origin/HEAD points to
+var defaultBranches = []string{"main", "master"}
+
+// DetectInstance returns the instance name of the git checkout containing dir
+//
+//	worktree: the directory name of a linked worktree; the main worktree has none
+//	branch:   the checked-out branch (short commit when detached); the default
+//	          branch (origin/HEAD, main or master) has none
+//
+// An empty mode turns instances off
+func DetectInstance(mode, dir string) (string, error) {
+	if mode == "" {
+		return "", nil
+	}
+	if err := checkInstanceMode(mode); err != nil {
+		return "", err
+	}
+	repo, err := findGitCheckout(dir)
+	if err != nil {
+		return "", err
+	}
+	return repo.instance(mode)
+}
+
+// InstanceProject is a resolved project name with its git instance
+type InstanceProject struct {
+	// Name is the project name with the instance (shop-feature-x)
+	Name string
+	// Base is the name without the instance, used for {project} in auto_domains
+	Base string
+	// Source is where Base came from (see ResolveProjectNameWithSource)
+	Source string
+	// Instance is "" outside instance mode
+	Instance string
+}
+
+// AutoSuffix reports whether the name comes from the directory, so a name
+// registered for another directory is suffixed instead of refused
+func (p InstanceProject) AutoSuffix() bool {
+	return p.Source == ProjectNameFromDirectory
+}
+
+// ResolveInstanceProject resolves the project name like Compose and adds the
+// instance from x-bootapp.instance
+// A name derived from the root of a linked worktree is taken from the main
+// worktree instead, so ../feature-x of shop becomes shop-feature-x
+func ResolveInstanceProject(flagName, projectDir string, compose *ComposeFile) (InstanceProject, error) {
+	name, source, err := ResolveProjectNameWithSource(flagName, projectDir, compose)
+	if err != nil {
+		return InstanceProject{}, err
+	}
+	project := InstanceProject{Name: name, Base: name, Source: source}
+	if compose == nil || compose.XBootapp.Instance == "" {
+		return project, nil
+	}
+
+	mode := compose.XBootapp.Instance
+	if err := checkInstanceMode(mode); err != nil {
+		return InstanceProject{}, err
+	}
+	repo, err := findGitCheckout(projectDir)
+	if err != nil {
+		return InstanceProject{}, err
+	}
+	if project.Instance, err = repo.instance(mode); err != nil {
+		return InstanceProject{}, err
+	}
+	if project.Instance == "" {
+		return project, nil
+	}
+
+	if source == ProjectNameFromDirectory && repo.linked {
+		absDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return InstanceProject{}, err
+		}
+		if absDir == repo.root {
+			if main := NormalizeProjectName(filepath.Base(repo.mainRoot())); main != "" {
+				project.Base = main
+			}
+		}
+	}
+	project.Name = InstanceProjectName(project.Base, project.Instance)
+	return project, nil
+}
+
+// checkInstanceMode validates an x-bootapp.instance value
+func checkInstanceMode(mode string) error {
+	if mode != InstanceWorktree && mode != InstanceBranch {
+		return fmt.Errorf("x-bootapp.instance: unknown mode %q (use %s or %s)", mode, InstanceWorktree, InstanceBranch)
+	}
+	return nil
+}
+
+// gitCheckout is a git working tree
+type gitCheckout struct {
+	// root is the top directory of the working tree
+	root string
+	// gitDir holds HEAD; commonDir holds the refs shared by all worktrees
+	gitDir    string
+	commonDir string
+	// linked is set for worktrees added with git worktree add
+	linked bool
+}
+
+// findGitCheckout walks up from dir to the working tree containing it
+// .git is a directory in the main worktree and a "gitdir:" file in linked
+// worktrees and submodules
+func findGitCheckout(dir string) (*gitCheckout, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return &gitCheckout{root: dir, gitDir: dotGit, commonDir: dotGit}, nil
+			}
+			return linkedCheckout(dir, dotGit)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("x-bootapp.instance: %s is not in a git repository", dir)
+		}
+		dir = parent
+	}
+}
+
+// linkedCheckout reads a .git file pointing at the real git directory
+func linkedCheckout(root, dotGit string) (*gitCheckout, error) {
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: expected 'gitdir: <path>'", dotGit)
+	}
+	gitDir = resolvePath(root, strings.TrimSpace(gitDir))
+
+	checkout := &gitCheckout{root: root, gitDir: gitDir, commonDir: gitDir}
+	// Worktrees live in <common dir>/worktrees/<name> and name their common dir
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		checkout.commonDir = resolvePath(gitDir, strings.TrimSpace(string(common)))
+		checkout.linked = true
+	}
+	return checkout, nil
+}
+
+// instance returns the checkout's instance name for mode (see DetectInstance)
+func (c *gitCheckout) instance(mode string) (string, error) {
+	if mode == InstanceWorktree {
+		if !c.linked {
+			return "", nil
+		}
+		return InstanceName(filepath.Base(c.root)), nil
+	}
+
+	head, err := os.ReadFile(filepath.Join(c.gitDir, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read git HEAD: %w", err)
+	}
+	ref := strings.TrimSpace(string(head))
+	branch, ok := strings.CutPrefix(ref, "ref: refs/heads/")
+	if !ok {
+		// Detached HEAD
+		if len(ref) > 7 {
+			ref = ref[:7]
+		}
+		return InstanceName(ref), nil
+	}
+	if branch == c.defaultBranch() {
+		return "", nil
+	}
+	for _, name := range defaultBranches {
+		if branch == name {
+			return "", nil
+		}
+	}
+	return InstanceName(branch), nil
+}
+
+// mainRoot returns the top directory of the main worktree, which holds the
+// common git directory
+func (c *gitCheckout) mainRoot() string {
+	return filepath.Dir(filepath.Clean(c.commonDir))
+}
+
+// defaultBranch returns the branch origin/HEAD points to, if known
+func (c *gitCheckout) defaultBranch() string {
+	data, err := os.ReadFile(filepath.Join(c.commonDir, "refs", "remotes", "origin", "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, _ := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/remotes/origin/")
+	return branch
+}
+
+// instanceInvalid matches runs of characters not allowed in a domain label
+var instanceInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// InstanceName turns a branch or directory name into a domain label:
+// feature/Login_Form -> feature-login-form
+func InstanceName(s string) string {
+	name := strings.Trim(instanceInvalid.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// InstanceProjectName returns the project name of an instance (api-feature-x)
+func InstanceProjectName(projectName, instance string) string {
+	if instance == "" {
+		return projectName
+	}
+	return projectName + "-" + instance
+}
+
+// ApplyInstance prefixes every service domain with the instance
+// (app.test -> feature-x.app.test), as if the prefixed domains were declared
+// in x-bootapp.domains and x-bootapp.ssl_domains
+func ApplyInstance(compose *ComposeFile, instance string) {
+	if instance == "" {
+		return
+	}
+	for name, service := range compose.Services {
+		ext := service.XBootapp
+		if ext.Disabled() {
+			continue
+		}
+		sslDomains := uniqueDomains(serviceSSLDomains(service))
+		isSSL := make(map[string]bool)
+		for _, domain := range sslDomains {
+			isSSL[domain] = true
+		}
+
+		var domains StringList
+		for _, domain := range uniqueDomains(serviceDomains(service)) {
+			if !isSSL[domain] {
+				domains = append(domains, instance+"."+domain)
+			}
+		}
+		var ssl StringList
+		for _, domain := range sslDomains {
+			ssl = append(ssl, instance+"."+domain)
+		}
+		if len(domains) == 0 && len(ssl) == 0 {
+			continue
+		}
+
+		ext.Domains = domains
+		ext.SSLDomains = ssl
+		service.XBootapp = ext
+		compose.Services[name] = service
+	}
+}
@@ -0,0 +1,240 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectInstance(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		// Main worktree on main, with origin/HEAD pointing at develop
+		"repo/.git/HEAD":                         "ref: refs/heads/main\n",
+		"repo/.git/refs/remotes/origin/HEAD":     "ref: refs/remotes/origin/develop\n",
+		"repo/src/.keep":                         "",
+		"repo/.git/worktrees/feature/HEAD":       "ref: refs/heads/feature/Login_Form\n",
+		"repo/.git/worktrees/feature/commondir":  "../..\n",
+		"repo/.git/worktrees/develop/HEAD":       "ref: refs/heads/develop\n",
+		"repo/.git/worktrees/develop/commondir":  "../..\n",
+		"repo/.git/worktrees/detached/HEAD":      "0123456789abcdef0123456789abcdef01234567\n",
+		"repo/.git/worktrees/detached/commondir": "../..\n",
+		"repo/.git/modules/sub/HEAD":             "ref: refs/heads/topic\n",
+		"wt/feature-x/.git":                      "gitdir: ../../repo/.git/worktrees/feature\n",
+		"wt/develop/.git":                        "gitdir: ../../repo/.git/worktrees/develop\n",
+		"wt/detached/.git":                       "gitdir: ../../repo/.git/worktrees/detached\n",
+		"repo/sub/.git":                          "gitdir: ../.git/modules/sub\n",
+		"plain/.keep":                            "",
+	})
+
+	tests := []struct {
+		name     string
+		mode     string
+		dir      string
+		expected string
+		wantErr  bool
+	}{
+		{name: "off", mode: "", dir: "plain", expected: ""},
+		{name: "unknown mode", mode: "tag", dir: "repo", wantErr: true},
+		{name: "not a repository", mode: InstanceBranch, dir: "plain", wantErr: true},
+		{name: "worktree: main worktree", mode: InstanceWorktree, dir: "repo/src", expected: ""},
+		{name: "worktree: linked worktree", mode: InstanceWorktree, dir: "wt/feature-x", expected: "feature-x"},
+		{name: "worktree: submodule", mode: InstanceWorktree, dir: "repo/sub", expected: ""},
+		{name: "branch: main", mode: InstanceBranch, dir: "repo/src", expected: ""},
+		{name: "branch: feature", mode: InstanceBranch, dir: "wt/feature-x", expected: "feature-login-form"},
+		{name: "branch: origin default", mode: InstanceBranch, dir: "wt/develop", expected: ""},
+		{name: "branch: detached", mode: InstanceBranch, dir: "wt/detached", expected: "0123456"},
+		{name: "branch: submodule", mode: InstanceBranch, dir: "repo/sub", expected: "topic"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance, err := DetectInstance(tt.mode, filepath.Join(tmpDir, tt.dir))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectInstance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if instance != tt.expected {
+				t.Errorf("DetectInstance() = %q, want %q", instance, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstanceName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"feature-x", "feature-x"},
+		{"feature/Login_Form", "feature-login-form"},
+		{"--fix..bug--", "fix-bug"},
+		{"", ""},
+		{strings.Repeat("a", 62) + "-bcd", strings.Repeat("a", 62)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := InstanceName(tt.input); got != tt.expected {
+				t.Errorf("InstanceName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyInstance(t *testing.T) {
+	disabled := false
+	compose := &ComposeFile{
+		Services: map[string]Service{
+			"app": {
+				Environment: map[string]interface{}{"DOMAIN": "app.test", "SSL_DOMAINS": "app.test,api.test"},
+			},
+			"admin": {
+				XBootapp: ServiceExtension{Domains: StringList{"admin.test"}},
+			},
+			"off": {
+				Environment: map[string]interface{}{"DOMAIN": "off.test"},
+				XBootapp:    ServiceExtension{Enable: &disabled},
+			},
+			"db": {},
+		},
+	}
+
+	ApplyInstance(compose, "feature-x")
+
+	wantDomains := map[string][]string{
+		"app":   {"feature-x.app.test", "feature-x.api.test"},
+		"admin": {"feature-x.admin.test"},
+	}
+	if got := ExtractServiceDomains(compose); !reflect.DeepEqual(got, wantDomains) {
+		t.Errorf("ExtractServiceDomains() = %v, want %v", got, wantDomains)
+	}
+	wantSSL := []string{"feature-x.app.test", "feature-x.api.test"}
+	if got := ExtractSSLDomains(compose); !reflect.DeepEqual(got, wantSSL) {
+		t.Errorf("ExtractSSLDomains() = %v, want %v", got, wantSSL)
+	}
+}
+
+func TestResolveInstanceProject(t *testing.T) {
+	t.Setenv("COMPOSE_PROJECT_NAME", "")
+	os.Unsetenv("COMPOSE_PROJECT_NAME")
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"shop/.git/HEAD":                          "ref: refs/heads/main\n",
+		"shop/.git/worktrees/feature-x/HEAD":      "ref: refs/heads/feature/login\n",
+		"shop/.git/worktrees/feature-x/commondir": "../..\n",
+		"feature-x/.git":                          "gitdir: ../shop/.git/worktrees/feature-x\n",
+		"feature-x/docker/.keep":                  "",
+	})
+	worktree := &ComposeFile{XBootapp: ProjectExtension{Instance: InstanceWorktree}}
+	branch := &ComposeFile{XBootapp: ProjectExtension{Instance: InstanceBranch}}
+	named := &ComposeFile{Name: "store", XBootapp: ProjectExtension{Instance: InstanceWorktree}}
+
+	tests := []struct {
+		name     string
+		dir      string
+		flag     string
+		compose  *ComposeFile
+		expected InstanceProject
+	}{
+		{"instances off", "feature-x", "", nil,
+			InstanceProject{Name: "feature-x", Base: "feature-x", Source: ProjectNameFromDirectory}},
+		{"main worktree", "shop", "", worktree,
+			InstanceProject{Name: "shop", Base: "shop", Source: ProjectNameFromDirectory}},
+		{"linked worktree without name", "feature-x", "", worktree,
+			InstanceProject{Name: "shop-feature-x", Base: "shop", Source: ProjectNameFromDirectory, Instance: "feature-x"}},
+		{"branch in linked worktree", "feature-x", "", branch,
+			InstanceProject{Name: "shop-feature-login", Base: "shop", Source: ProjectNameFromDirectory, Instance: "feature-login"}},
+		{"subdirectory of linked worktree", "feature-x/docker", "", worktree,
+			InstanceProject{Name: "docker-feature-x", Base: "docker", Source: ProjectNameFromDirectory, Instance: "feature-x"}},
+		{"compose name", "feature-x", "", named,
+			InstanceProject{Name: "store-feature-x", Base: "store", Source: "name", Instance: "feature-x"}},
+		{"flag", "feature-x", "mine", worktree,
+			InstanceProject{Name: "mine-feature-x", Base: "mine", Source: "-p", Instance: "feature-x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveInstanceProject(tt.flag, filepath.Join(tmpDir, tt.dir), tt.compose)
+			if err != nil {
+				t.Fatalf("ResolveInstanceProject() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ResolveInstanceProject() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstanceProject_AutoSuffixDockerMode(t *testing.T) {
+	t.Setenv("COMPOSE_PROJECT_NAME", "")
+	os.Unsetenv("COMPOSE_PROJECT_NAME")
+	// docker compose config names the project after the directory either way
+	fakeComposeConfig(t, `{"name": "api", "services": {"app": {"image": "nginx"}}}`)
+
+	tests := []struct {
+		name     string
+		file     string
+		expected bool
+	}{
+		{"name from the directory", "services:\n  app:\n    image: nginx\n", true},
+		{"name in the file", "name: api\nservices:\n  app:\n    image: nginx\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "api")
+			writeFiles(t, dir, map[string]string{"docker-compose.yml": tt.file})
+			compose, _, err := LoadProject([]string{filepath.Join(dir, "docker-compose.yml")}, "", ParserDocker)
+			if err != nil {
+				t.Fatalf("LoadProject() error = %v", err)
+			}
+
+			project, err := ResolveInstanceProject("", dir, compose)
+			if err != nil {
+				t.Fatalf("ResolveInstanceProject() error = %v", err)
+			}
+			if project.Name != "api" || project.AutoSuffix() != tt.expected {
+				t.Errorf("ResolveInstanceProject() = %+v, AutoSuffix() = %v, want api, %v", project, project.AutoSuffix(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveInstanceProject_DockerMode(t *testing.T) {
+	t.Setenv("COMPOSE_PROJECT_NAME", "")
+	os.Unsetenv("COMPOSE_PROJECT_NAME")
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"shop/.git/HEAD":                          "ref: refs/heads/main\n",
+		"shop/.git/worktrees/feature-x/HEAD":      "ref: refs/heads/feature/login\n",
+		"shop/.git/worktrees/feature-x/commondir": "../..\n",
+		"feature-x/.git":                          "gitdir: ../shop/.git/worktrees/feature-x\n",
+		"feature-x/docker-compose.yml":            "x-bootapp:\n  instance: worktree\nservices:\n  app:\n    networks: [backend]\nnetworks:\n  backend: {}\n",
+	})
+	// What docker compose config prints in the linked worktree
+	fakeComposeConfig(t, `{"name": "feature-x", "x-bootapp": {"instance": "worktree"},
+  "services": {"app": {"image": "nginx", "networks": {"backend": null}}},
+  "networks": {"backend": {"name": "feature-x_backend"}}}`)
+
+	dir := filepath.Join(tmpDir, "feature-x")
+	compose, _, err := LoadProject([]string{filepath.Join(dir, "docker-compose.yml")}, "", ParserDocker)
+	if err != nil {
+		t.Fatalf("LoadProject() error = %v", err)
+	}
+	project, err := ResolveInstanceProject("", dir, compose)
+	if err != nil {
+		t.Fatalf("ResolveInstanceProject() error = %v", err)
+	}
+	expected := InstanceProject{Name: "shop-feature-x", Base: "shop", Source: ProjectNameFromDirectory, Instance: "feature-x"}
+	if project != expected {
+		t.Errorf("ResolveInstanceProject() = %+v, want %+v", project, expected)
+	}
+
+	// Each instance gets its own networks
+	networks := ProjectNetworks(compose, project.Name)
+	if len(networks) != 1 || networks[0].DockerName != "shop-feature-x_backend" {
+		t.Errorf("ProjectNetworks() = %+v, want backend as shop-feature-x_backend", networks)
+	}
+}
@@ -0,0 +1,277 @@
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LookupFunc returns the value of a variable and whether it is set
+type LookupFunc func(name string) (string, bool)
+
+// ProjectEnv returns the variables used for interpolation in projectDir:
+// the project .env file, overridden by the process environment
+func ProjectEnv(projectDir string) (map[string]string, error) {
+	return loadEnv([]string{filepath.Join(projectDir, ".env")}, false)
+}
+
+// loadEnv merges the given .env files in order, then the process environment
+// Missing files are an error only when required is set
+func loadEnv(paths []string, required bool) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, path := range paths {
+		values, err := LoadDotEnv(path)
+		if err != nil {
+			if os.IsNotExist(err) && !required {
+				continue
+			}
+			return nil, err
+		}
+		for k, v := range values {
+			env[k] = v
+		}
+	}
+
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	return env, nil
+}
+
+// MapLookup adapts a map to a LookupFunc
+func MapLookup(env map[string]string) LookupFunc {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+// LoadDotEnv parses a .env file
+// Supports: KEY=VALUE, export KEY=VALUE, # comments, single/double quotes
+// Unquoted and double-quoted values are interpolated with earlier entries
+// and the process environment
+func LoadDotEnv(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	env := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := env[name]
+		return value, ok
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: invalid line %q", filepath.Base(path), lineNum, line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			// Single quotes: literal
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			if value, err = Interpolate(value, lookup); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", filepath.Base(path), lineNum, err)
+			}
+		default:
+			// Strip inline comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			if value, err = Interpolate(value, lookup); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", filepath.Base(path), lineNum, err)
+			}
+		}
+
+		env[key] = value
+	}
+
+	return env, scanner.Err()
+}
+
+// Interpolate expands Compose variable references in value
+// Supports: $VAR, ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?err},
+// ${VAR?err}, ${VAR:+alt}, ${VAR+alt} and $$ escaping
+func Interpolate(value string, lookup LookupFunc) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '$' || i+1 >= len(value) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		next := value[i+1]
+		switch {
+		case next == '$':
+			// $$ -> literal $
+			sb.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(value, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format for %q: unterminated ${", value)
+			}
+			expanded, err := expandBraced(value[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(expanded)
+			i = end
+		case isVarStart(next):
+			j := i + 1
+			for j < len(value) && isVarChar(value[j]) {
+				j++
+			}
+			v, _ := lookup(value[i+1 : j])
+			sb.WriteString(v)
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// expandBraced expands the content of ${...}
+func expandBraced(expr string, lookup LookupFunc) (string, error) {
+	nameEnd := 0
+	for nameEnd < len(expr) && isVarChar(expr[nameEnd]) {
+		nameEnd++
+	}
+	name := expr[:nameEnd]
+	if name == "" || !isVarStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format for ${%s}", expr)
+	}
+
+	value, set := lookup(name)
+	rest := expr[nameEnd:]
+	if rest == "" {
+		return value, nil
+	}
+
+	// ":" variants also treat empty as unset
+	op := rest[:1]
+	emptyIsUnset := false
+	if op == ":" && len(rest) >= 2 {
+		emptyIsUnset = true
+		op = rest[1:2]
+		rest = rest[2:]
+	} else {
+		rest = rest[1:]
+	}
+	present := set && (!emptyIsUnset || value != "")
+
+	switch op {
+	case "-":
+		if present {
+			return value, nil
+		}
+		return Interpolate(rest, lookup)
+	case "?":
+		if present {
+			return value, nil
+		}
+		msg, err := Interpolate(rest, lookup)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "is not set"
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, msg)
+	case "+":
+		if present {
+			return Interpolate(rest, lookup)
+		}
+		return "", nil
+	default:
+		return "", fmt.Errorf("invalid interpolation format for ${%s}", expr)
+	}
+}
+
+// matchingBrace returns the index of the } closing the { at open
+func matchingBrace(value string, open int) int {
+	depth := 0
+	for i := open; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isVarStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isVarChar(c byte) bool {
+	return isVarStart(c) || (c >= '0' && c <= '9')
+}
+
+// interpolateNode expands variables in every scalar value of a YAML tree
+// Mapping keys are left untouched, as in docker compose
+func interpolateNode(node *yaml.Node, lookup LookupFunc) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, lookup); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateNode(node.Content[i], lookup); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		value, err := Interpolate(node.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if value != node.Value {
+			node.Value = value
+			// Let unquoted scalars resolve their type again (e.g. ports: ${PORT})
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	}
+	return nil
+}
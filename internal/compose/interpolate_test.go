@@ -0,0 +1,196 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{
+		"APP_DOMAIN": "myapp.test",
+		"EMPTY":      "",
+		"PORT":       "8080",
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"no variables", "myapp.test", "myapp.test"},
+		{"simple", "$APP_DOMAIN", "myapp.test"},
+		{"braced", "${APP_DOMAIN}", "myapp.test"},
+		{"embedded", "www.${APP_DOMAIN}", "www.myapp.test"},
+		{"unset is empty", "${MISSING}", ""},
+		{"default when unset", "${MISSING:-app.test}", "app.test"},
+		{"default when empty", "${EMPTY:-app.test}", "app.test"},
+		{"dash default keeps empty", "${EMPTY-app.test}", ""},
+		{"dash default when unset", "${MISSING-app.test}", "app.test"},
+		{"default not used when set", "${APP_DOMAIN:-app.test}", "myapp.test"},
+		{"nested default", "${MISSING:-${APP_DOMAIN}}", "myapp.test"},
+		{"alternate when set", "${APP_DOMAIN:+set}", "set"},
+		{"alternate when empty", "${EMPTY:+set}", ""},
+		{"plus alternate when empty", "${EMPTY+set}", "set"},
+		{"escaped dollar", "$$APP_DOMAIN", "$APP_DOMAIN"},
+		{"escaped braced", "$${APP_DOMAIN}", "${APP_DOMAIN}"},
+		{"trailing dollar", "cost$", "cost$"},
+		{"dollar before non-name", "$1", "$1"},
+		{"port mapping", "${PORT}:80", "8080:80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Interpolate(tt.input, MapLookup(env))
+			if err != nil {
+				t.Fatalf("Interpolate(%q) error = %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Interpolate(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	env := map[string]string{"EMPTY": ""}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"required unset", "${MISSING?domain required}"},
+		{"required empty", "${EMPTY:?domain required}"},
+		{"unterminated", "${APP_DOMAIN"},
+		{"invalid name", "${1ABC}"},
+		{"invalid operator", "${APP_DOMAIN*x}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Interpolate(tt.input, MapLookup(env)); err == nil {
+				t.Errorf("Interpolate(%q) should return error", tt.input)
+			}
+		})
+	}
+
+	// Set-but-empty satisfies "?" without colon
+	if _, err := Interpolate("${EMPTY?required}", MapLookup(env)); err != nil {
+		t.Errorf("Interpolate(${EMPTY?required}) error = %v", err)
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, ".env")
+	content := `# comment
+APP_DOMAIN=myapp.test
+export API_DOMAIN=api.${APP_DOMAIN}
+QUOTED="hello world"
+LITERAL='${APP_DOMAIN}'
+INLINE=value # comment
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	env, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatalf("LoadDotEnv() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"APP_DOMAIN": "myapp.test",
+		"API_DOMAIN": "api.myapp.test",
+		"QUOTED":     "hello world",
+		"LITERAL":    "${APP_DOMAIN}",
+		"INLINE":     "value",
+		"EMPTY":      "",
+	}
+	for key, want := range expected {
+		if got, ok := env[key]; !ok || got != want {
+			t.Errorf("env[%q] = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadDotEnv_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, ".env")
+	os.WriteFile(path, []byte("NOT A VARIABLE\n"), 0644)
+
+	if _, err := LoadDotEnv(path); err == nil {
+		t.Error("LoadDotEnv() should return error for invalid line")
+	}
+}
+
+func TestParseComposeFile_Interpolation(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	content := `name: ${PROJECT:-shop}
+services:
+  app:
+    image: nginx
+    environment:
+      DOMAIN: ${APP_DOMAIN:-app.test}
+      SSL_DOMAINS: www.${APP_DOMAIN:-app.test}
+      PRICE: $$5
+`
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("APP_DOMAIN=shop.test\n"), 0644); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	if compose.Name != "shop" {
+		t.Errorf("Name = %q, want %q", compose.Name, "shop")
+	}
+
+	domains := ExtractServiceDomains(compose)["app"]
+	if len(domains) != 2 || domains[0] != "shop.test" || domains[1] != "www.shop.test" {
+		t.Errorf("app domains = %v, want [shop.test www.shop.test]", domains)
+	}
+
+	ssl := ExtractSSLDomains(compose)
+	if len(ssl) != 1 || ssl[0] != "www.shop.test" {
+		t.Errorf("SSL domains = %v, want [www.shop.test]", ssl)
+	}
+
+	env := compose.Services["app"].Environment.(map[string]interface{})
+	if env["PRICE"] != "$5" {
+		t.Errorf("PRICE = %v, want $5", env["PRICE"])
+	}
+}
+
+func TestParseComposeFile_ProcessEnvOverridesDotEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    environment:\n      DOMAIN: ${BOOTAPP_TEST_DOMAIN}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("BOOTAPP_TEST_DOMAIN=dotenv.test\n"), 0644)
+	t.Setenv("BOOTAPP_TEST_DOMAIN", "shell.test")
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+	if domain := ExtractDomain(compose); domain != "shell.test" {
+		t.Errorf("ExtractDomain() = %q, want %q", domain, "shell.test")
+	}
+}
+
+func TestParseComposeFile_RequiredVariable(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(composePath, []byte("services:\n  app:\n    environment:\n      DOMAIN: ${BOOTAPP_TEST_UNSET:?set a domain}\n"), 0644)
+
+	if _, err := ParseComposeFile(composePath); err == nil {
+		t.Error("ParseComposeFile() should fail for missing required variable")
+	}
+}
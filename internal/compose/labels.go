@@ -0,0 +1,268 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LabelParser extracts domains from a service's labels
+// Labels are normalized to a map regardless of the list or mapping form
+type LabelParser func(labels map[string]string) []string
+
+type labelParserEntry struct {
+	name  string
+	parse LabelParser
+}
+
+var labelParsers []labelParserEntry
+
+func init() {
+	RegisterLabelParser("traefik", parseTraefikLabels)
+	RegisterLabelParser("traefik-v1", parseTraefikV1Labels)
+	RegisterLabelParser("caddy", parseCaddyLabels)
+	RegisterLabelParser("nginx-proxy", parseNginxProxyLabels)
+}
+
+// RegisterLabelParser adds a reverse-proxy label dialect for domain discovery
+// Parsers run in registration order; registering an existing name replaces it
+func RegisterLabelParser(name string, parser LabelParser) {
+	for i, entry := range labelParsers {
+		if entry.name == name {
+			labelParsers[i].parse = parser
+			return
+		}
+	}
+	labelParsers = append(labelParsers, labelParserEntry{name: name, parse: parser})
+}
+
+// extractDomainsFromLabels runs every registered label parser
+func extractDomainsFromLabels(labels interface{}) []string {
+	mapping := toMapping(labels)
+	if len(mapping) == 0 {
+		return nil
+	}
+
+	normalized := make(map[string]string, len(mapping))
+	for key, value := range mapping {
+		if value != nil {
+			normalized[key] = fmt.Sprint(value)
+		} else {
+			normalized[key] = ""
+		}
+	}
+
+	var domains []string
+	for _, entry := range labelParsers {
+		domains = append(domains, entry.parse(normalized)...)
+	}
+	return domains
+}
+
+// parseTraefikLabels reads Traefik v2/v3 router rules:
+//
+//	traefik.http.routers.<name>.rule=Host(`a.test`) || HostRegexp(`^b\.test$`)
+//	traefik.tcp.routers.<name>.rule=HostSNI(`db.test`)
+func parseTraefikLabels(labels map[string]string) []string {
+	var domains []string
+	for _, key := range sortedKeys(labels) {
+		if !strings.HasPrefix(key, "traefik.") || !strings.HasSuffix(key, ".rule") {
+			continue
+		}
+		rule := labels[key]
+		switch {
+		case strings.HasPrefix(key, "traefik.http.routers."):
+			domains = append(domains, extractTraefikHosts(rule)...)
+			for _, pattern := range extractRuleArgs(rule, "HostRegexp") {
+				if host, ok := literalRegexpHost(pattern); ok {
+					domains = append(domains, host)
+				}
+			}
+		case strings.HasPrefix(key, "traefik.tcp.routers."):
+			domains = append(domains, literalHosts(extractRuleArgs(rule, "HostSNI"))...)
+		}
+	}
+	return domains
+}
+
+// parseTraefikV1Labels reads Traefik v1 frontend rules:
+//
+//	traefik.frontend.rule=Host:a.test,b.test;PathPrefix:/api
+//	traefik.<service>.frontend.rule=Host:c.test
+func parseTraefikV1Labels(labels map[string]string) []string {
+	var domains []string
+	for _, key := range sortedKeys(labels) {
+		if !strings.HasPrefix(key, "traefik.") || !strings.HasSuffix(key, "frontend.rule") {
+			continue
+		}
+		for _, matcher := range strings.Split(labels[key], ";") {
+			matcher = strings.TrimSpace(matcher)
+			if !strings.HasPrefix(matcher, "Host:") {
+				continue
+			}
+			hosts := strings.Split(strings.TrimPrefix(matcher, "Host:"), ",")
+			domains = append(domains, literalHosts(hosts)...)
+		}
+	}
+	return domains
+}
+
+// parseCaddyLabels reads caddy-docker-proxy site addresses:
+//
+//	caddy=a.test, https://b.test:8443
+//	caddy_1=c.test
+func parseCaddyLabels(labels map[string]string) []string {
+	var domains []string
+	for _, key := range sortedKeys(labels) {
+		if key != "caddy" && !isCaddyIndexedKey(key) {
+			continue
+		}
+		for _, address := range splitDomains(labels[key]) {
+			domains = append(domains, literalHosts([]string{caddyAddressHost(address)})...)
+		}
+	}
+	return domains
+}
+
+// parseNginxProxyLabels reads nginx-proxy / acme-companion hosts set as labels
+// (VIRTUAL_HOST as an environment variable is handled with the env keys)
+func parseNginxProxyLabels(labels map[string]string) []string {
+	var domains []string
+	for _, key := range []string{"VIRTUAL_HOST", "LETSENCRYPT_HOST"} {
+		if value, ok := labels[key]; ok {
+			domains = append(domains, literalHosts(splitDomains(value))...)
+		}
+	}
+	return domains
+}
+
+// extractTraefikHosts extracts hosts from Traefik Host() rule
+// Supports: Host(`a.com`) || Host(`b.com`) and Host(`a.com`, `b.com`)
+func extractTraefikHosts(rule string) []string {
+	return extractRuleArgs(rule, "Host")
+}
+
+// extractRuleArgs extracts the backtick-quoted arguments of every
+// matcher(...) call in a Traefik rule
+func extractRuleArgs(rule, matcher string) []string {
+	var args []string
+	remaining := rule
+	call := matcher + "("
+
+	for {
+		start := strings.Index(remaining, call)
+		if start == -1 {
+			break
+		}
+		// Skip longer matcher names ending in the same text (e.g. "VirtualHost(")
+		if start > 0 && isIdentChar(remaining[start-1]) {
+			remaining = remaining[start+len(call):]
+			continue
+		}
+		start += len(call)
+
+		// Find the closing )
+		depth := 1
+		end := start
+		for i := start; i < len(remaining) && depth > 0; i++ {
+			if remaining[i] == '(' {
+				depth++
+			} else if remaining[i] == ')' {
+				depth--
+			}
+			if depth == 0 {
+				end = i
+			}
+		}
+
+		if end <= start {
+			break
+		}
+
+		// Parse backtick-quoted arguments: `a`, `b`
+		content := remaining[start:end]
+		for {
+			tickStart := strings.Index(content, "`")
+			if tickStart == -1 {
+				break
+			}
+			tickEnd := strings.Index(content[tickStart+1:], "`")
+			if tickEnd == -1 {
+				break
+			}
+			if arg := content[tickStart+1 : tickStart+1+tickEnd]; arg != "" {
+				args = append(args, arg)
+			}
+			content = content[tickStart+1+tickEnd+1:]
+		}
+
+		remaining = remaining[end:]
+	}
+
+	return args
+}
+
+// literalRegexpHost returns the host matched by a HostRegexp pattern that is
+// really a plain name (e.g. ^api\.test$); real patterns cannot go in /etc/hosts
+func literalRegexpHost(pattern string) (string, bool) {
+	host := strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	host = strings.ReplaceAll(host, `\.`, ".")
+	if strings.ContainsAny(host, `\{}[]()*+?|^$`) {
+		return "", false
+	}
+	return host, host != ""
+}
+
+// literalHosts drops wildcard, regex and template entries
+func literalHosts(hosts []string) []string {
+	var result []string
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" || strings.ContainsAny(host, "*~{}^$") {
+			continue
+		}
+		result = append(result, host)
+	}
+	return result
+}
+
+// caddyAddressHost strips the scheme, port and path from a Caddy site address
+func caddyAddressHost(address string) string {
+	if i := strings.Index(address, "://"); i != -1 {
+		address = address[i+3:]
+	}
+	if i := strings.Index(address, "/"); i != -1 {
+		address = address[:i]
+	}
+	if i := strings.LastIndex(address, ":"); i != -1 {
+		address = address[:i]
+	}
+	return address
+}
+
+// isCaddyIndexedKey matches caddy_0, caddy_1, ... used for multiple sites
+func isCaddyIndexedKey(key string) bool {
+	suffix := strings.TrimPrefix(key, "caddy_")
+	if suffix == key || suffix == "" {
+		return false
+	}
+	for _, c := range suffix {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+package compose
+
+import (
+	"testing"
+)
+
+func TestExtractDomainsFromLabels_Dialects(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   interface{}
+		expected []string
+	}{
+		{
+			"traefik HostSNI",
+			map[string]interface{}{"traefik.tcp.routers.db.rule": "HostSNI(`db.test`)"},
+			[]string{"db.test"},
+		},
+		{
+			"traefik HostSNI catch-all",
+			map[string]interface{}{"traefik.tcp.routers.db.rule": "HostSNI(`*`)"},
+			nil,
+		},
+		{
+			"traefik HostRegexp literal",
+			map[string]interface{}{"traefik.http.routers.api.rule": "HostRegexp(`^api\\.test$`)"},
+			[]string{"api.test"},
+		},
+		{
+			"traefik HostRegexp pattern",
+			map[string]interface{}{"traefik.http.routers.api.rule": "HostRegexp(`{sub:[a-z]+}.api.test`)"},
+			nil,
+		},
+		{
+			"traefik Host and HostRegexp",
+			map[string]interface{}{"traefik.http.routers.web.rule": "Host(`web.test`) || HostRegexp(`^www\\.web\\.test$`)"},
+			[]string{"web.test", "www.web.test"},
+		},
+		{
+			"traefik v1 frontend rule",
+			[]interface{}{"traefik.frontend.rule=Host:a.test,b.test;PathPrefix:/api"},
+			[]string{"a.test", "b.test"},
+		},
+		{
+			"traefik v1 per-service frontend rule",
+			map[string]interface{}{"traefik.admin.frontend.rule": "Host:admin.test"},
+			[]string{"admin.test"},
+		},
+		{
+			"caddy",
+			map[string]interface{}{
+				"caddy":               "app.test, https://www.app.test:8443",
+				"caddy.reverse_proxy": "{{upstreams 80}}",
+			},
+			[]string{"app.test", "www.app.test"},
+		},
+		{
+			"caddy indexed sites",
+			map[string]interface{}{
+				"caddy_0": "a.test",
+				"caddy_1": "http://b.test/path",
+				"caddy_x": "ignored.test",
+			},
+			[]string{"a.test", "b.test"},
+		},
+		{
+			"caddy wildcard and port-only",
+			map[string]interface{}{"caddy": "*.app.test :8080"},
+			nil,
+		},
+		{
+			"nginx-proxy labels",
+			[]interface{}{"VIRTUAL_HOST=shop.test,*.shop.test", "LETSENCRYPT_HOST=shop.test"},
+			[]string{"shop.test", "shop.test"},
+		},
+		{
+			"unrelated labels",
+			map[string]interface{}{"com.example.team": "web", "traefik.enable": "true"},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractDomainsFromLabels(tt.labels)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("extractDomainsFromLabels() = %v, want %v", result, tt.expected)
+			}
+			for i, d := range result {
+				if d != tt.expected[i] {
+					t.Errorf("extractDomainsFromLabels()[%d] = %q, want %q", i, d, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractRuleArgs_SkipsLongerMatchers(t *testing.T) {
+	result := extractRuleArgs("VirtualHost(`a.test`) && Host(`b.test`)", "Host")
+	if len(result) != 1 || result[0] != "b.test" {
+		t.Errorf("extractRuleArgs() = %v, want [b.test]", result)
+	}
+}
+
+func TestRegisterLabelParser(t *testing.T) {
+	saved := labelParsers
+	defer func() { labelParsers = saved }()
+	labelParsers = append([]labelParserEntry(nil), saved...)
+
+	RegisterLabelParser("custom", func(labels map[string]string) []string {
+		if host, ok := labels["com.example.host"]; ok {
+			return []string{host}
+		}
+		return nil
+	})
+
+	result := extractDomainsFromLabels(map[string]interface{}{"com.example.host": "custom.test"})
+	if len(result) != 1 || result[0] != "custom.test" {
+		t.Errorf("extractDomainsFromLabels() = %v, want [custom.test]", result)
+	}
+
+	// Registering the same name replaces the parser
+	count := len(labelParsers)
+	RegisterLabelParser("custom", func(map[string]string) []string { return nil })
+	if len(labelParsers) != count {
+		t.Errorf("parsers = %d, want %d after replacing", len(labelParsers), count)
+	}
+}
@@ -0,0 +1,729 @@
+package compose
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yejune/bootapp/internal/cert"
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a single lint finding with its location in a compose file
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", d.File, d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// Known keys of the x-bootapp blocks
+var (
+	projectExtensionKeys = []string{"subnet", "network", "ipv6", "hostnames", "instance", "auto_domains", "hooks", "services"}
+	autoDomainsKeys      = []string{"template", "services", "ssl"}
+	hookKeys             = []string{"pre_up", "post_up", "pre_down", "post_down"}
+	serviceExtensionKeys = []string{"enable", "domains", "ssl_domains", "certs", "hostnames", "auto_domain"}
+)
+
+// domainRef is a domain found in a compose file, with its location
+type domainRef struct {
+	domain string
+	ssl    bool
+	file   string
+	node   *yaml.Node
+}
+
+// lintService collects what the linter saw for one service across all files
+type lintService struct {
+	defined bool
+	// file and node locate the service's first definition
+	file      string
+	node      *yaml.Node
+	disabled  bool
+	declared  []domainRef // x-bootapp / bootapp.* labels
+	heuristic []domainRef // environment and reverse-proxy labels
+	// hostnames is the service's hostnames setting, nil if unset
+	hostnames *bool
+	// naming holds the hostname, domainname and container_name entries
+	naming map[string]domainRef
+}
+
+type extensionRef struct {
+	service string
+	file    string
+	node    *yaml.Node
+}
+
+type linter struct {
+	fileOrder  map[string]int
+	diags      []Diagnostic
+	services   map[string]*lintService
+	extensions []extensionRef
+	// networks maps declared network names to whether they are external
+	networks map[string]bool
+	// routable is the last x-bootapp.network value seen
+	routable *extensionRef
+	// hostnames is x-bootapp.hostnames
+	hostnames bool
+}
+
+// Lint checks compose files and reports every problem with its location:
+// invalid domain names, .local domains, duplicate domains across services,
+// SSL domains that are not routed, unsupported network configs, unknown
+// x-bootapp.network references and
+// x-bootapp keys or bootapp.* labels
+// Included files are checked too, and domains that services get through
+// extends or env_file are checked like the others
+// Variables are interpolated like ParseComposeFiles before checking
+// projectDir overrides the project directory (default: the first file's directory)
+func Lint(paths []string, projectDir string) ([]Diagnostic, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files specified")
+	}
+	if projectDir == "" {
+		projectDir = filepath.Dir(paths[0])
+	}
+	env, err := ProjectEnv(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	l := &linter{fileOrder: make(map[string]int), services: make(map[string]*lintService), networks: make(map[string]bool)}
+	for _, path := range paths {
+		l.lintFile(path, env, nil)
+	}
+	l.addResolvedDomains(paths, projectDir, env)
+	l.checkDomains()
+	l.checkNetworks()
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		a, b := l.diags[i], l.diags[j]
+		if a.File != b.File {
+			return l.fileOrder[a.File] < l.fileOrder[b.File]
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diags, nil
+}
+
+// addResolvedDomains records the domains services get once extends and
+// env_file are resolved and that the files themselves do not show, located at
+// the service's definition
+func (l *linter) addResolvedDomains(paths []string, projectDir string, env map[string]string) {
+	resolved, err := parseComposeFiles(paths, projectDir, env)
+	if err != nil {
+		// Problems the file checks found usually explain the failure already
+		for _, d := range l.diags {
+			if d.Severity == SeverityError {
+				return
+			}
+		}
+		l.report(paths[0], nil, SeverityError, "%v", err)
+		return
+	}
+
+	for name, service := range resolved.Services {
+		svc, ok := l.services[name]
+		if !ok || svc.node == nil {
+			continue
+		}
+		if service.XBootapp.Disabled() {
+			svc.disabled = true
+			continue
+		}
+
+		known := make(map[string]bool)
+		for _, ref := range append(append([]domainRef(nil), svc.declared...), svc.heuristic...) {
+			known[ref.domain] = true
+		}
+		if ref, ok := svc.hostnameRef(l.hostnames); ok {
+			known[ref.domain] = true
+		}
+		isSSL := make(map[string]bool)
+		for _, domain := range serviceSSLDomains(service) {
+			isSSL[domain] = true
+		}
+		for _, domain := range serviceDomains(service) {
+			if known[domain] {
+				continue
+			}
+			known[domain] = true
+			ref := domainRef{domain: domain, ssl: isSSL[domain], file: svc.file, node: svc.node}
+			if service.XBootapp.Declared() {
+				svc.declared = append(svc.declared, ref)
+			} else {
+				svc.heuristic = append(svc.heuristic, ref)
+			}
+		}
+	}
+}
+
+func (l *linter) report(file string, node *yaml.Node, severity, format string, args ...interface{}) {
+	d := Diagnostic{File: file, Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		d.Line, d.Column = node.Line, node.Column
+	}
+	l.diags = append(l.diags, d)
+}
+
+func (l *linter) service(name string) *lintService {
+	svc, ok := l.services[name]
+	if !ok {
+		svc = &lintService{}
+		l.services[name] = svc
+	}
+	return svc
+}
+
+var errorLinePattern = regexp.MustCompile(`line (\d+): (.*)`)
+
+// reportError converts a parse error into a diagnostic, keeping its line number
+func (l *linter) reportError(file string, err error) {
+	d := Diagnostic{File: file, Severity: SeverityError, Message: err.Error()}
+	if m := errorLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		d.Line, _ = strconv.Atoi(m[1])
+		d.Column = 1
+		d.Message = m[2]
+	}
+	l.diags = append(l.diags, d)
+}
+
+// lintFile checks one file and the files it includes
+// stack holds the files being checked, for include cycle detection
+func (l *linter) lintFile(path string, env map[string]string, stack []string) {
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		for _, p := range stack {
+			if p == absPath {
+				return
+			}
+		}
+		stack = append(stack, absPath)
+	}
+	if _, ok := l.fileOrder[path]; !ok {
+		l.fileOrder[path] = len(l.fileOrder)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.reportError(path, err)
+		return
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		l.reportError(path, err)
+		return
+	}
+	if len(root.Content) == 0 {
+		return
+	}
+	if err := interpolateNode(&root, MapLookup(env)); err != nil {
+		l.reportError(path, err)
+	}
+
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		l.report(path, doc, SeverityError, "compose file must be a mapping")
+		return
+	}
+
+	forEachPair(doc, func(key, value *yaml.Node) {
+		switch key.Value {
+		case "services":
+			forEachPair(value, func(name, service *yaml.Node) {
+				l.lintService(path, name.Value, service)
+			})
+		case "networks":
+			forEachPair(value, func(name, config *yaml.Node) {
+				var network Network
+				_ = config.Decode(&network)
+				l.networks[name.Value] = network.IsExternal()
+			})
+		case "x-bootapp":
+			l.lintProjectExtension(path, value)
+		case "include":
+			l.lintIncludes(path, value, stack)
+		}
+	})
+}
+
+// lintIncludes checks the files of each include entry with the variables of
+// the included project, like resolveIncludes loads them
+func (l *linter) lintIncludes(file string, node *yaml.Node, stack []string) {
+	var include interface{}
+	if err := node.Decode(&include); err != nil {
+		l.report(file, node, SeverityError, "invalid include: %v", err)
+		return
+	}
+	refs, err := parseIncludeRefs(include)
+	if err != nil {
+		l.report(file, node, SeverityError, "%v", err)
+		return
+	}
+	for i, ref := range refs {
+		at := node
+		if node.Kind == yaml.SequenceNode && i < len(node.Content) {
+			at = node.Content[i]
+		}
+		paths, _, env, err := includeProject(ref, filepath.Dir(file))
+		if err != nil {
+			l.report(file, at, SeverityError, "include %s: %v", ref.Paths[0], err)
+			continue
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				l.report(file, at, SeverityError, "include %s: %v", ref.Paths[0], err)
+				continue
+			}
+			l.lintFile(path, env, stack)
+		}
+	}
+}
+
+func (l *linter) lintProjectExtension(file string, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(file, node, SeverityError, "x-bootapp must be a mapping")
+		return
+	}
+
+	forEachPair(node, func(key, value *yaml.Node) {
+		switch key.Value {
+		case "subnet":
+			if _, _, err := net.ParseCIDR(value.Value); err != nil {
+				l.report(file, value, SeverityError, "invalid x-bootapp.subnet %q (expected CIDR, e.g. 172.25.0.0/16)", value.Value)
+			}
+		case "network":
+			l.routable = &extensionRef{service: value.Value, file: file, node: value}
+		case "ipv6":
+			var enabled bool
+			if err := value.Decode(&enabled); err != nil {
+				l.report(file, value, SeverityError, "x-bootapp.ipv6 must be a boolean")
+			}
+		case "hostnames":
+			if err := value.Decode(&l.hostnames); err != nil {
+				l.report(file, value, SeverityError, "x-bootapp.hostnames must be a boolean")
+			}
+		case "instance":
+			if value.Value != InstanceWorktree && value.Value != InstanceBranch {
+				l.report(file, value, SeverityError, "x-bootapp.instance must be %s or %s", InstanceWorktree, InstanceBranch)
+			}
+		case "auto_domains":
+			l.checkKeys(file, value, "x-bootapp.auto_domains", autoDomainsKeys)
+			if template := mappingValue(value, "template"); template != nil {
+				if _, err := ExpandDomainTemplate(template.Value, "service", "project"); err != nil {
+					l.report(file, template, SeverityError, "%v", err)
+				}
+			}
+			if services := mappingValue(value, "services"); services != nil && services.Value != AutoDomainsAll && services.Value != AutoDomainsPublished {
+				l.report(file, services, SeverityError, "x-bootapp.auto_domains.services must be %s or %s", AutoDomainsAll, AutoDomainsPublished)
+			}
+		case "hooks":
+			l.checkKeys(file, value, "x-bootapp.hooks", hookKeys)
+		case "services":
+			forEachPair(value, func(name, ext *yaml.Node) {
+				l.extensions = append(l.extensions, extensionRef{service: name.Value, file: file, node: name})
+				l.lintServiceExtension(file, name.Value, "x-bootapp.services."+name.Value, ext)
+			})
+		default:
+			l.report(file, key, SeverityError, "unknown x-bootapp key '%s' (known: %s)", key.Value, strings.Join(projectExtensionKeys, ", "))
+		}
+	})
+}
+
+func (l *linter) lintServiceExtension(file, service, path string, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		l.report(file, node, SeverityError, "%s must be a mapping", path)
+		return
+	}
+	svc := l.service(service)
+
+	forEachPair(node, func(key, value *yaml.Node) {
+		switch key.Value {
+		case "enable":
+			var enable bool
+			if err := value.Decode(&enable); err != nil {
+				l.report(file, value, SeverityError, "%s.enable must be a boolean", path)
+			} else if !enable {
+				svc.disabled = true
+			}
+		case "hostnames":
+			var hostnames bool
+			if err := value.Decode(&hostnames); err != nil {
+				l.report(file, value, SeverityError, "%s.hostnames must be a boolean", path)
+			} else {
+				svc.hostnames = &hostnames
+			}
+		case "auto_domain":
+			var autoDomain bool
+			if err := value.Decode(&autoDomain); err != nil {
+				l.report(file, value, SeverityError, "%s.auto_domain must be a boolean", path)
+			}
+		case "domains", "ssl_domains":
+			items := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				items = value.Content
+			}
+			for _, item := range items {
+				for _, domain := range splitDomains(item.Value) {
+					svc.declared = append(svc.declared, domainRef{domain: domain, ssl: key.Value == "ssl_domains", file: file, node: item})
+				}
+			}
+		case "certs":
+			forEachPair(value, func(layout, _ *yaml.Node) {
+				if !cert.IsValidLayout(layout.Value) {
+					l.report(file, layout, SeverityError, "unknown certificate layout '%s' (use nginx, haproxy, apache or java)", layout.Value)
+				}
+			})
+		default:
+			l.report(file, key, SeverityError, "unknown %s key '%s' (known: %s)", path, key.Value, strings.Join(serviceExtensionKeys, ", "))
+		}
+	})
+}
+
+func (l *linter) lintService(file, name string, node *yaml.Node) {
+	svc := l.service(name)
+	svc.defined = true
+	if svc.node == nil {
+		svc.file, svc.node = file, node
+	}
+
+	forEachPair(node, func(key, value *yaml.Node) {
+		switch key.Value {
+		case "environment":
+			l.lintEnvironment(file, svc, value)
+		case "labels":
+			l.lintLabels(file, name, svc, value)
+		case "x-bootapp":
+			l.lintServiceExtension(file, name, "services."+name+".x-bootapp", value)
+		case "hostname", "domainname", "container_name":
+			if svc.naming == nil {
+				svc.naming = make(map[string]domainRef)
+			}
+			svc.naming[key.Value] = domainRef{domain: value.Value, file: file, node: value}
+		case "networks":
+			forEachPair(value, func(network, config *yaml.Node) {
+				forEachPair(config, func(option, _ *yaml.Node) {
+					if option.Value == "ipv6_address" {
+						l.report(file, option, SeverityError, "service '%s' has a static IPv6 address in network '%s'; bootapp only manages IPv4 subnets", name, network.Value)
+					}
+				})
+			})
+		case "ports":
+			l.lintPorts(file, name, value)
+		case "network_mode":
+			if value.Value != "" && value.Value != "bridge" && value.Value != "default" {
+				l.report(file, value, SeverityError, "service '%s' uses network_mode '%s'; it will not get a project IP for its domains", name, value.Value)
+			}
+		}
+	})
+}
+
+// lintPorts checks short and long syntax port entries
+func (l *linter) lintPorts(file, service string, node *yaml.Node) {
+	if node.Kind != yaml.SequenceNode {
+		l.report(file, node, SeverityError, "service '%s': ports must be a list", service)
+		return
+	}
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			if _, err := ParsePort(item.Value); err != nil {
+				l.report(file, item, SeverityError, "service '%s': %v", service, err)
+			}
+		case yaml.MappingNode:
+			var port Port
+			if err := item.Decode(&port); err != nil || port.Target <= 0 || port.Target > 65535 {
+				l.report(file, item, SeverityError, "service '%s': port needs a target between 1 and 65535", service)
+			}
+		default:
+			l.report(file, item, SeverityError, "service '%s': invalid port entry", service)
+		}
+	}
+}
+
+// lintEnvironment records domains from DOMAIN/DOMAINS/SSL_DOMAINS/... entries
+func (l *linter) lintEnvironment(file string, svc *lintService, node *yaml.Node) {
+	add := func(key, value string, at *yaml.Node) {
+		if !containsString(domainEnvKeys, key) {
+			return
+		}
+		for _, domain := range splitDomains(value) {
+			svc.heuristic = append(svc.heuristic, domainRef{domain: domain, ssl: containsString(sslEnvKeys, key), file: file, node: at})
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		forEachPair(node, func(key, value *yaml.Node) {
+			add(key.Value, value.Value, value)
+		})
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if key, value, ok := strings.Cut(item.Value, "="); ok {
+				add(key, value, item)
+			}
+		}
+	}
+}
+
+// lintLabels checks bootapp.* labels and records domains from proxy labels
+func (l *linter) lintLabels(file, name string, svc *lintService, node *yaml.Node) {
+	check := func(key, value string, at *yaml.Node) {
+		if !strings.HasPrefix(key, LabelPrefix) {
+			for _, domain := range extractDomainsFromLabels(map[string]interface{}{key: value}) {
+				svc.heuristic = append(svc.heuristic, domainRef{domain: domain, file: file, node: at})
+			}
+			return
+		}
+
+		ext, unknown, err := parseBootappLabels(map[string]interface{}{key: value})
+		if err != nil {
+			l.report(file, at, SeverityError, "service '%s': %v", name, err)
+			return
+		}
+		for _, label := range unknown {
+			l.report(file, at, SeverityError, "service '%s': unknown label %s", name, label)
+		}
+		if ext.Disabled() {
+			svc.disabled = true
+		}
+		if ext.Hostnames != nil {
+			svc.hostnames = ext.Hostnames
+		}
+		for _, domain := range ext.Domains {
+			svc.declared = append(svc.declared, domainRef{domain: domain, file: file, node: at})
+		}
+		for _, domain := range ext.SSLDomains {
+			svc.declared = append(svc.declared, domainRef{domain: domain, ssl: true, file: file, node: at})
+		}
+		for layout := range ext.Certs {
+			if !cert.IsValidLayout(layout) {
+				l.report(file, at, SeverityError, "unknown certificate layout '%s' (use nginx, haproxy, apache or java)", layout)
+			}
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		forEachPair(node, func(key, value *yaml.Node) {
+			check(key.Value, value.Value, key)
+		})
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(item.Value, "=")
+			check(key, value, item)
+		}
+	}
+}
+
+func (l *linter) checkKeys(file string, node *yaml.Node, path string, known []string) {
+	if node.Kind != yaml.MappingNode {
+		l.report(file, node, SeverityError, "%s must be a mapping", path)
+		return
+	}
+	forEachPair(node, func(key, _ *yaml.Node) {
+		if !containsString(known, key.Value) {
+			l.report(file, key, SeverityError, "unknown %s key '%s' (known: %s)", path, key.Value, strings.Join(known, ", "))
+		}
+	})
+}
+
+// checkNetworks checks that x-bootapp.network names a project network
+func (l *linter) checkNetworks() {
+	if l.routable == nil || l.routable.service == DefaultNetwork {
+		return
+	}
+	external, ok := l.networks[l.routable.service]
+	switch {
+	case !ok:
+		l.report(l.routable.file, l.routable.node, SeverityError, "x-bootapp.network: network '%s' is not declared", l.routable.service)
+	case external:
+		l.report(l.routable.file, l.routable.node, SeverityError, "x-bootapp.network: network '%s' is external and cannot be routed", l.routable.service)
+	}
+}
+
+// checkDomains runs the checks that need every file: domain names, .local,
+// duplicates across services and unrouted SSL domains
+func (l *linter) checkDomains() {
+	for _, ref := range l.extensions {
+		if !l.services[ref.service].defined {
+			l.report(ref.file, ref.node, SeverityError, "x-bootapp.services: service '%s' is not defined", ref.service)
+		}
+	}
+
+	names := make([]string, 0, len(l.services))
+	for name := range l.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type serviceRef struct {
+		service string
+		domainRef
+	}
+	var all []serviceRef
+
+	for _, name := range names {
+		svc := l.services[name]
+		if svc.disabled {
+			continue
+		}
+		// Declared domains replace the heuristics, as in ExtractServiceDomains
+		refs := svc.heuristic
+		if len(svc.declared) > 0 {
+			refs = svc.declared
+		} else if ref, ok := svc.hostnameRef(l.hostnames); ok {
+			refs = append(refs, ref)
+		}
+
+		routed := make(map[string]bool)
+		var routedList []string
+		for _, ref := range refs {
+			if !ref.ssl && !routed[ref.domain] {
+				routed[ref.domain] = true
+				routedList = append(routedList, ref.domain)
+			}
+		}
+
+		reported := make(map[string]bool)
+		for _, ref := range refs {
+			if reported[ref.domain] {
+				continue
+			}
+			reported[ref.domain] = true
+
+			if reason := checkDomainName(ref.domain); reason != "" {
+				l.report(ref.file, ref.node, SeverityError, "invalid domain '%s': %s", ref.domain, reason)
+				continue
+			}
+			if strings.HasSuffix(strings.ToLower(ref.domain), ".local") {
+				l.report(ref.file, ref.node, SeverityWarning, "domain '%s' uses .local, which is reserved for mDNS and can resolve slowly or conflict (use .test)", ref.domain)
+			}
+			if ref.ssl && len(routed) > 0 && !routed[ref.domain] {
+				l.report(ref.file, ref.node, SeverityWarning, "SSL domain '%s' is not among the domains routed to service '%s' (%s)", ref.domain, name, strings.Join(routedList, ", "))
+			}
+			all = append(all, serviceRef{service: name, domainRef: ref})
+		}
+	}
+
+	// Report duplicates at every occurrence after the first, in file order
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.file != b.file {
+			return l.fileOrder[a.file] < l.fileOrder[b.file]
+		}
+		if a.node.Line != b.node.Line {
+			return a.node.Line < b.node.Line
+		}
+		return a.node.Column < b.node.Column
+	})
+	first := make(map[string]serviceRef)
+	for _, ref := range all {
+		owner, ok := first[ref.domain]
+		if !ok {
+			first[ref.domain] = ref
+			continue
+		}
+		if owner.service != ref.service {
+			l.report(ref.file, ref.node, SeverityError, "domain '%s' is also used by service '%s' (%s:%d)", ref.domain, owner.service, filepath.Base(owner.file), owner.node.Line)
+		}
+	}
+}
+
+// hostnameRef returns the domain built from the service's naming fields like
+// hostnameDomain, located at the hostname or container_name entry
+func (svc *lintService) hostnameRef(projectDefault bool) (domainRef, bool) {
+	enabled := projectDefault
+	if svc.hostnames != nil {
+		enabled = *svc.hostnames
+	}
+	if !enabled {
+		return domainRef{}, false
+	}
+
+	at, ok := svc.naming["hostname"]
+	if !ok {
+		at, ok = svc.naming["container_name"]
+	}
+	if !ok {
+		return domainRef{}, false
+	}
+	service := Service{Hostname: at.domain, Domainname: svc.naming["domainname"].domain}
+	domain := hostnameDomain(service)
+	if domain == "" {
+		return domainRef{}, false
+	}
+	at.domain = domain
+	return at, true
+}
+
+// checkDomainName validates a host name against RFC 1123
+// Returns the reason it is invalid, or "" if valid
+func checkDomainName(domain string) string {
+	if len(domain) > 253 {
+		return "longer than 253 characters"
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" {
+			return "empty label"
+		}
+		if len(label) > 63 {
+			return fmt.Sprintf("label '%s' is longer than 63 characters", label)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Sprintf("label '%s' starts or ends with a hyphen", label)
+		}
+		for _, c := range label {
+			if !(c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				return fmt.Sprintf("invalid character %q", c)
+			}
+		}
+	}
+	return ""
+}
+
+// forEachPair calls fn for each key/value pair of a mapping node
+func forEachPair(node *yaml.Node, fn func(key, value *yaml.Node)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fn(node.Content[i], node.Content[i+1])
+	}
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package compose
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lintFiles(t *testing.T, files map[string]string, names ...string) []Diagnostic {
+	t.Helper()
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, files)
+
+	var paths []string
+	for _, name := range names {
+		paths = append(paths, filepath.Join(tmpDir, name))
+	}
+	diags, err := Lint(paths, "")
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	return diags
+}
+
+// findDiagnostic returns the first diagnostic whose message contains text
+func findDiagnostic(diags []Diagnostic, text string) (Diagnostic, bool) {
+	for _, d := range diags {
+		if strings.Contains(d.Message, text) {
+			return d, true
+		}
+	}
+	return Diagnostic{}, false
+}
+
+func TestLint_Clean(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": `services:
+  app:
+    environment:
+      DOMAIN: app.test
+      SSL_DOMAINS: app.test
+  db:
+    image: mysql
+`,
+	}, "docker-compose.yml")
+
+	if len(diags) != 0 {
+		t.Errorf("Lint() = %v, want no diagnostics", diags)
+	}
+}
+
+func TestLint_Instance(t *testing.T) {
+	for _, mode := range []string{InstanceWorktree, InstanceBranch} {
+		diags := lintFiles(t, map[string]string{
+			"docker-compose.yml": "x-bootapp:\n  instance: " + mode + "\nservices:\n  app: {}\n",
+		}, "docker-compose.yml")
+		if _, ok := findDiagnostic(diags, "x-bootapp.instance"); ok {
+			t.Errorf("instance: %s reported: %v", mode, diags)
+		}
+	}
+
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": "x-bootapp:\n  instance: tag\nservices:\n  app: {}\n",
+	}, "docker-compose.yml")
+	if d, ok := findDiagnostic(diags, "x-bootapp.instance must be"); !ok || d.Line != 2 {
+		t.Errorf("missing x-bootapp.instance diagnostic on line 2 in %v", diags)
+	}
+}
+
+func TestLint_Diagnostics(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  subnet: 172.25.0.0
+  hook:
+    pre_up: ./setup.sh
+  services:
+    ghost:
+      enable: false
+services:
+  app:
+    environment:
+      DOMAIN: app.test
+      SSL_DOMAINS: secure.test
+  api:
+    environment:
+      - DOMAIN=app.test,bad_name.test
+  web:
+    labels:
+      bootapp.domain: web.test
+      bootapp.domains: web.local
+    x-bootapp:
+      certs:
+        tomcat: ./certs
+  db:
+    networks:
+      backend:
+        ipv4_address: 172.25.1.5
+        ipv6_address: fd00::5
+    ports:
+      - "5432:5432/http"
+      - published: 8080
+networks:
+  backend: {}
+  shared:
+    external: true
+`,
+	}, "docker-compose.yml")
+
+	tests := []struct {
+		text     string
+		line     int
+		column   int
+		severity string
+	}{
+		{"invalid x-bootapp.subnet", 2, 11, SeverityError},
+		{"unknown x-bootapp key 'hook'", 3, 3, SeverityError},
+		{"service 'ghost' is not defined", 6, 5, SeverityError},
+		{"not among the domains routed", 12, 20, SeverityWarning},
+		{"domain 'app.test' is also used by service 'app'", 15, 9, SeverityError},
+		{"invalid domain 'bad_name.test'", 15, 9, SeverityError},
+		{"unknown label bootapp.domain", 18, 7, SeverityError},
+		{"domain 'web.local' uses .local", 19, 7, SeverityWarning},
+		{"unknown certificate layout 'tomcat'", 22, 9, SeverityError},
+		{"static IPv6 address in network 'backend'", 27, 9, SeverityError},
+		{"unknown protocol \"http\"", 29, 9, SeverityError},
+		{"port needs a target", 30, 9, SeverityError},
+	}
+
+	for _, tt := range tests {
+		d, ok := findDiagnostic(diags, tt.text)
+		if !ok {
+			t.Errorf("missing diagnostic %q in %v", tt.text, diags)
+			continue
+		}
+		if d.Line != tt.line || d.Column != tt.column || d.Severity != tt.severity {
+			t.Errorf("%q at %d:%d (%s), want %d:%d (%s)", tt.text, d.Line, d.Column, d.Severity, tt.line, tt.column, tt.severity)
+		}
+	}
+
+	// Diagnostics are sorted by position
+	for i := 1; i < len(diags); i++ {
+		if diags[i].Line < diags[i-1].Line {
+			t.Errorf("diagnostics not sorted: %v before %v", diags[i-1], diags[i])
+		}
+	}
+}
+
+func TestLint_RoutableNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		want    string
+	}{
+		{"declared", "frontend", ""},
+		{"implicit default", "default", ""},
+		{"undeclared", "backend", "network 'backend' is not declared"},
+		{"external", "shared", "network 'shared' is external"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := lintFiles(t, map[string]string{
+				"docker-compose.yml": `x-bootapp:
+  network: ` + tt.network + `
+services:
+  app:
+    networks: [frontend, shared]
+networks:
+  frontend: {}
+  shared:
+    external: true
+`,
+			}, "docker-compose.yml")
+
+			if tt.want == "" {
+				if len(diags) != 0 {
+					t.Errorf("Lint() = %v, want no diagnostics", diags)
+				}
+				return
+			}
+			d, ok := findDiagnostic(diags, tt.want)
+			if !ok {
+				t.Fatalf("missing diagnostic %q in %v", tt.want, diags)
+			}
+			if d.Line != 2 || d.Severity != SeverityError {
+				t.Errorf("%q at line %d (%s), want line 2 (error)", tt.want, d.Line, d.Severity)
+			}
+		})
+	}
+}
+
+func TestLint_Hostnames(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  hostnames: true
+services:
+  api:
+    hostname: api
+    domainname: shop.test
+  web:
+    container_name: api.shop.test
+  worker:
+    hostname: bad_name.shop.test
+    x-bootapp:
+      hostnames: false
+`,
+	}, "docker-compose.yml")
+
+	d, ok := findDiagnostic(diags, "domain 'api.shop.test' is also used by service 'api'")
+	if !ok {
+		t.Fatalf("missing duplicate hostname diagnostic in %v", diags)
+	}
+	if d.Line != 8 || d.Column != 21 {
+		t.Errorf("duplicate reported at %d:%d, want 8:21", d.Line, d.Column)
+	}
+	if _, ok := findDiagnostic(diags, "bad_name"); ok {
+		t.Errorf("unexpected diagnostic for a service with hostnames disabled: %v", diags)
+	}
+}
+
+func TestLint_DeclaredDomainsReplaceHeuristics(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": `services:
+  app:
+    environment:
+      DOMAIN: shared.test
+    x-bootapp:
+      domains: [app.test]
+  api:
+    environment:
+      DOMAIN: shared.test
+  worker:
+    environment:
+      DOMAIN: shared.test
+    labels:
+      - bootapp.enable=false
+`,
+	}, "docker-compose.yml")
+
+	if len(diags) != 0 {
+		t.Errorf("Lint() = %v, want no diagnostics", diags)
+	}
+}
+
+func TestLint_MultipleFiles(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": "services:\n  app:\n    environment:\n      DOMAIN: app.test\n",
+		"docker-compose.override.yml": `x-bootapp:
+  services:
+    app:
+      ssl_domains: [app.test]
+services:
+  admin:
+    environment:
+      DOMAIN: app.test
+`,
+	}, "docker-compose.yml", "docker-compose.override.yml")
+
+	// app is defined in the base file, so the override's x-bootapp entry is valid
+	if _, ok := findDiagnostic(diags, "is not defined"); ok {
+		t.Errorf("unexpected undefined service diagnostic: %v", diags)
+	}
+	d, ok := findDiagnostic(diags, "also used by service")
+	if !ok {
+		t.Fatalf("missing duplicate domain diagnostic in %v", diags)
+	}
+	if !strings.HasSuffix(d.File, "docker-compose.override.yml") || d.Line != 8 {
+		t.Errorf("duplicate reported at %s:%d, want docker-compose.override.yml:8", d.File, d.Line)
+	}
+}
+
+func TestLint_ResolvedServices(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+		file  string
+		line  int
+	}{
+		{
+			name: "included file",
+			files: map[string]string{
+				"docker-compose.yml": "include:\n  - shop/compose.yml\nservices:\n  app:\n    environment:\n      DOMAIN: app.test\n",
+				"shop/compose.yml":   "services:\n  shop:\n    environment:\n      DOMAIN: app.test\n",
+			},
+			want: "domain 'app.test' is also used by service 'app'",
+			file: "compose.yml",
+			line: 4,
+		},
+		{
+			name: "included file variables",
+			files: map[string]string{
+				"docker-compose.yml": "include:\n  - shop/compose.yml\n",
+				"shop/.env":          "SHOP_DOMAIN=bad_name.test\n",
+				"shop/compose.yml":   "services:\n  shop:\n    environment:\n      DOMAIN: ${SHOP_DOMAIN}\n",
+			},
+			want: "invalid domain 'bad_name.test'",
+			file: "compose.yml",
+			line: 4,
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"docker-compose.yml": "services:\n  app:\n    image: nginx\ninclude:\n  - missing.yml\n",
+			},
+			want: "include missing.yml",
+			file: "docker-compose.yml",
+			line: 5,
+		},
+		{
+			name: "extends",
+			files: map[string]string{
+				"docker-compose.yml": "services:\n  app:\n    environment:\n      DOMAIN: app.test\n  admin:\n    extends:\n      service: app\n",
+			},
+			want: "domain 'app.test' is also used by service 'app'",
+			file: "docker-compose.yml",
+			line: 6,
+		},
+		{
+			name: "env_file",
+			files: map[string]string{
+				"docker-compose.yml": "services:\n  app:\n    env_file: app.env\n",
+				"app.env":            "DOMAIN=app.local\n",
+			},
+			want: "domain 'app.local' uses .local",
+			file: "docker-compose.yml",
+			line: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := lintFiles(t, tt.files, "docker-compose.yml")
+			d, ok := findDiagnostic(diags, tt.want)
+			if !ok {
+				t.Fatalf("missing diagnostic %q in %v", tt.want, diags)
+			}
+			if filepath.Base(d.File) != tt.file || d.Line != tt.line {
+				t.Errorf("%q reported at %s:%d, want %s:%d", tt.want, filepath.Base(d.File), d.Line, tt.file, tt.line)
+			}
+		})
+	}
+}
+
+func TestLint_ProjectDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker/docker-compose.yml": "services:\n  app:\n    environment:\n      DOMAIN: ${APP_DOMAIN}\n",
+		"docker/.env":               "APP_DOMAIN=app.test\n",
+		".env":                      "APP_DOMAIN=app.local\n",
+	})
+
+	diags, err := Lint([]string{filepath.Join(tmpDir, "docker", "docker-compose.yml")}, tmpDir)
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if _, ok := findDiagnostic(diags, "domain 'app.local' uses .local"); !ok {
+		t.Errorf("Lint() = %v, want the project directory's .env to be used", diags)
+	}
+}
+
+func TestLint_SyntaxError(t *testing.T) {
+	diags := lintFiles(t, map[string]string{
+		"docker-compose.yml": "services:\n  app:\n    image: nginx\n   bad: indent\n",
+	}, "docker-compose.yml")
+
+	if len(diags) != 1 || diags[0].Line == 0 || diags[0].Severity != SeverityError {
+		t.Errorf("Lint() = %v, want one located syntax error", diags)
+	}
+}
+
+func TestCheckDomainName(t *testing.T) {
+	tests := []struct {
+		domain string
+		valid  bool
+	}{
+		{"app.test", true},
+		{"my-app.example.test", true},
+		{"db", true},
+		{"xn--bcher-kva.test", true},
+		{"bad_name.test", false},
+		{"-app.test", false},
+		{"app-.test", false},
+		{"app..test", false},
+		{"app.test.", false},
+		{strings.Repeat("a", 64) + ".test", false},
+		{"*.app.test", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			reason := checkDomainName(tt.domain)
+			if (reason == "") != tt.valid {
+				t.Errorf("checkDomainName(%q) = %q, want valid=%v", tt.domain, reason, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package compose
+
+import (
+	"strings"
+)
+
+// mergeComposeFiles merges override into base with Compose semantics:
+//   - scalars (name, image, build) are replaced
+//   - mappings (environment, labels, networks, depends_on) are merged by key
+//   - sequences (ports, env_file) are appended
+func mergeComposeFiles(base, override *ComposeFile) *ComposeFile {
+	if override.Name != "" {
+		base.Name = override.Name
+	}
+	if override.Version != "" {
+		base.Version = override.Version
+	}
+
+	if len(override.Services) > 0 && base.Services == nil {
+		base.Services = make(map[string]Service)
+	}
+	for name, service := range override.Services {
+		if existing, ok := base.Services[name]; ok {
+			base.Services[name] = mergeService(existing, service)
+		} else {
+			base.Services[name] = service
+		}
+	}
+
+	if len(override.Networks) > 0 && base.Networks == nil {
+		base.Networks = make(map[string]Network)
+	}
+	for name, network := range override.Networks {
+		base.Networks[name] = network
+	}
+
+	base.XBootapp = mergeProjectExtension(base.XBootapp, override.XBootapp)
+
+	if len(override.X) > 0 && base.X == nil {
+		base.X = make(map[string]interface{})
+	}
+	for key, value := range override.X {
+		base.X[key] = value
+	}
+
+	return base
+}
+
+func mergeService(base, override Service) Service {
+	if override.Image != "" {
+		base.Image = override.Image
+	}
+	if override.Build != nil {
+		base.Build = override.Build
+	}
+	if override.Hostname != "" {
+		base.Hostname = override.Hostname
+	}
+	if override.Domainname != "" {
+		base.Domainname = override.Domainname
+	}
+	if override.ContainerName != "" {
+		base.ContainerName = override.ContainerName
+	}
+
+	base.Environment = mergeMappings(base.Environment, override.Environment)
+	base.Labels = mergeMappings(base.Labels, override.Labels)
+	base.Networks = mergeMappings(base.Networks, override.Networks)
+	base.DependsOn = mergeMappings(base.DependsOn, override.DependsOn)
+	base.EnvFile = appendSequences(base.EnvFile, override.EnvFile)
+	if len(override.Profiles) > 0 {
+		base.Profiles = override.Profiles
+	}
+
+	for _, port := range override.Ports {
+		if !containsPort(base.Ports, port) {
+			base.Ports = append(base.Ports, port)
+		}
+	}
+
+	base.XBootapp = mergeServiceExtension(base.XBootapp, override.XBootapp)
+
+	return base
+}
+
+// mergeMappings merges two values that may be either a list or a mapping
+// Returns base unchanged when override is empty
+func mergeMappings(base, override interface{}) interface{} {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		return override
+	}
+
+	result := toMapping(base)
+	for key, value := range toMapping(override) {
+		result[key] = value
+	}
+	return result
+}
+
+// toMapping converts the list form ("KEY=VALUE" or "name") into a mapping
+// Entries without a value map to nil
+func toMapping(value interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			result[key] = val
+		}
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				continue
+			}
+			if key, val, found := strings.Cut(str, "="); found {
+				result[key] = val
+			} else {
+				result[str] = nil
+			}
+		}
+	}
+
+	return result
+}
+
+// appendSequences appends two values that may each be a single item or a list
+func appendSequences(base, override interface{}) interface{} {
+	if override == nil {
+		return base
+	}
+	if base == nil {
+		return override
+	}
+
+	var result []interface{}
+	for _, v := range []interface{}{base, override} {
+		if list, ok := v.([]interface{}); ok {
+			result = append(result, list...)
+		} else {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports Ports, port Port) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestToMapping(t *testing.T) {
+	list := toMapping([]interface{}{"DOMAIN=app.test", "EMPTY=", "BARE"})
+	if list["DOMAIN"] != "app.test" || list["EMPTY"] != "" {
+		t.Errorf("toMapping(list) = %v", list)
+	}
+	if v, ok := list["BARE"]; !ok || v != nil {
+		t.Errorf("toMapping(list)[BARE] = %v, want nil", v)
+	}
+
+	m := toMapping(map[string]interface{}{"DOMAIN": "app.test"})
+	if m["DOMAIN"] != "app.test" {
+		t.Errorf("toMapping(map) = %v", m)
+	}
+}
+
+func TestMergeService(t *testing.T) {
+	base := Service{
+		Image:       "nginx",
+		Environment: []interface{}{"DOMAIN=app.test", "DEBUG=0"},
+		Labels:      map[string]interface{}{"a": "1"},
+		Ports:       Ports{{Target: 80, Published: "80", Protocol: "tcp"}},
+		EnvFile:     ".env.app",
+	}
+	override := Service{
+		Image:       "nginx:alpine",
+		Environment: map[string]interface{}{"DEBUG": "1", "SSL_DOMAINS": "app.test"},
+		Labels:      []interface{}{"b=2"},
+		Ports:       Ports{{Target: 80, Published: "80", Protocol: "tcp"}, {Target: 443, Published: "443", Protocol: "tcp"}},
+		EnvFile:     []interface{}{".env.local"},
+		XBootapp:    ServiceExtension{Certs: map[string]string{"nginx": "./certs"}},
+	}
+
+	result := mergeService(base, override)
+
+	if result.Image != "nginx:alpine" {
+		t.Errorf("Image = %q, want %q", result.Image, "nginx:alpine")
+	}
+
+	env := result.Environment.(map[string]interface{})
+	if env["DOMAIN"] != "app.test" || env["DEBUG"] != "1" || env["SSL_DOMAINS"] != "app.test" {
+		t.Errorf("Environment = %v", env)
+	}
+
+	labels := result.Labels.(map[string]interface{})
+	if labels["a"] != "1" || labels["b"] != "2" {
+		t.Errorf("Labels = %v", labels)
+	}
+
+	if len(result.Ports) != 2 {
+		t.Errorf("Ports = %v, want [80:80 443:443]", result.Ports)
+	}
+
+	envFiles := result.EnvFile.([]interface{})
+	if len(envFiles) != 2 || envFiles[0] != ".env.app" || envFiles[1] != ".env.local" {
+		t.Errorf("EnvFile = %v", envFiles)
+	}
+
+	if result.XBootapp.Certs["nginx"] != "./certs" {
+		t.Errorf("XBootapp.Certs = %v", result.XBootapp.Certs)
+	}
+}
+
+func TestMergeService_KeepsBaseWhenOverrideEmpty(t *testing.T) {
+	base := Service{
+		Image:       "nginx",
+		Environment: []interface{}{"DOMAIN=app.test"},
+	}
+	result := mergeService(base, Service{})
+
+	if result.Image != "nginx" {
+		t.Errorf("Image = %q, want %q", result.Image, "nginx")
+	}
+	if _, ok := result.Environment.([]interface{}); !ok {
+		t.Errorf("Environment should be unchanged, got %T", result.Environment)
+	}
+}
+
+func TestParseComposeFiles_Override(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "docker-compose.yml")
+	override := filepath.Join(tmpDir, "docker-compose.override.yml")
+
+	os.WriteFile(base, []byte(`name: shop
+services:
+  app:
+    image: nginx
+    environment:
+      DOMAIN: app.test
+  db:
+    image: mysql
+`), 0644)
+	os.WriteFile(override, []byte(`services:
+  app:
+    environment:
+      DOMAIN: local.app.test
+    labels:
+      - "traefik.http.routers.app.rule=Host(`+"`admin.test`"+`)"
+  mail:
+    image: mailhog/mailhog
+    environment:
+      VIRTUAL_HOST: mail.test
+`), 0644)
+
+	compose, err := ParseComposeFiles([]string{base, override})
+	if err != nil {
+		t.Fatalf("ParseComposeFiles() error = %v", err)
+	}
+
+	if compose.Name != "shop" {
+		t.Errorf("Name = %q, want %q", compose.Name, "shop")
+	}
+	if len(compose.Services) != 3 {
+		t.Errorf("Services count = %d, want 3", len(compose.Services))
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	app := serviceDomains["app"]
+	if len(app) != 2 || app[0] != "local.app.test" || app[1] != "admin.test" {
+		t.Errorf("app domains = %v, want [local.app.test admin.test]", app)
+	}
+	if mail := serviceDomains["mail"]; len(mail) != 1 || mail[0] != "mail.test" {
+		t.Errorf("mail domains = %v, want [mail.test]", mail)
+	}
+}
+
+func TestOverrideFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "docker-compose.yml")
+	os.WriteFile(base, []byte("services: {}"), 0644)
+
+	if got := OverrideFile(base); got != "" {
+		t.Errorf("OverrideFile() = %q, want empty without override", got)
+	}
+
+	override := filepath.Join(tmpDir, "docker-compose.override.yml")
+	os.WriteFile(override, []byte("services: {}"), 0644)
+	if got := OverrideFile(base); got != override {
+		t.Errorf("OverrideFile() = %q, want %q", got, override)
+	}
+
+	// Explicit variants have no automatic override
+	if got := OverrideFile(filepath.Join(tmpDir, "docker-compose.local.yml")); got != "" {
+		t.Errorf("OverrideFile(local) = %q, want empty", got)
+	}
+}
+
+func TestFindComposeFile_IgnoresOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	os.Chdir(tmpDir)
+
+	os.WriteFile("docker-compose.yml", []byte("version: '3'"), 0644)
+	os.WriteFile("docker-compose.override.yml", []byte("version: '3'"), 0644)
+
+	path, err := FindComposeFile()
+	if err != nil {
+		t.Fatalf("FindComposeFile() error = %v", err)
+	}
+	if filepath.Base(path) != "docker-compose.yml" {
+		t.Errorf("FindComposeFile() = %q, want docker-compose.yml", path)
+	}
+}
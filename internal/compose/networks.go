@@ -0,0 +1,152 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultNetwork is the network Compose creates for services without a networks key
+const DefaultNetwork = "default"
+
+// ProjectNetwork is a project network that bootapp creates and assigns a subnet
+type ProjectNetwork struct {
+	Name       string // key in the compose file ("default" for the implicit network)
+	DockerName string // name of the network as created by Compose
+	Driver     string
+	Internal   bool
+	Attachable bool
+	// Subnet is the subnet from the network's ipam config, if the user set one
+	Subnet string
+	// Services lists the services attached to this network, sorted
+	Services []string
+	// StaticIPs maps service name to its ipv4_address on this network
+	StaticIPs map[string]string
+}
+
+// ProjectNetworks returns the networks of the project that bootapp manages,
+// "default" first and the rest sorted by name
+// Only networks used by an active service are included, as Compose only
+// creates those; external networks are not managed
+func ProjectNetworks(compose *ComposeFile, projectName string) []ProjectNetwork {
+	networks := make(map[string]*ProjectNetwork)
+
+	add := func(name string) *ProjectNetwork {
+		if pn, ok := networks[name]; ok {
+			return pn
+		}
+		pn := &ProjectNetwork{Name: name, DockerName: projectName + "_" + name, StaticIPs: make(map[string]string)}
+		if network, ok := compose.Networks[name]; ok {
+			if network.Name != "" {
+				pn.DockerName = network.Name
+			}
+			pn.Driver = network.Driver
+			pn.Internal = network.Internal
+			pn.Attachable = network.Attachable
+			if network.IPAM != nil && len(network.IPAM.Config) > 0 {
+				pn.Subnet = network.IPAM.Config[0].Subnet
+			}
+		}
+		networks[name] = pn
+		return pn
+	}
+
+	for serviceName, service := range compose.Services {
+		names := ServiceNetworks(service)
+		if len(names) == 0 {
+			pn := add(DefaultNetwork)
+			pn.Services = append(pn.Services, serviceName)
+			continue
+		}
+		for _, name := range names {
+			if network, ok := compose.Networks[name]; ok && network.IsExternal() {
+				continue
+			}
+			pn := add(name)
+			pn.Services = append(pn.Services, serviceName)
+			if ip := serviceStaticIP(service, name); ip != "" {
+				pn.StaticIPs[serviceName] = ip
+			}
+		}
+	}
+
+	result := make([]ProjectNetwork, 0, len(networks))
+	for _, pn := range networks {
+		sort.Strings(pn.Services)
+		result = append(result, *pn)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Name == DefaultNetwork) != (result[j].Name == DefaultNetwork) {
+			return result[i].Name == DefaultNetwork
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// RoutableNetwork returns the managed network whose container IPs are used for
+// domains: x-bootapp.network if set, otherwise "default", otherwise the first one
+func RoutableNetwork(compose *ComposeFile, networks []ProjectNetwork) (ProjectNetwork, error) {
+	if len(networks) == 0 {
+		return ProjectNetwork{}, fmt.Errorf("project has no managed networks")
+	}
+
+	name := compose.XBootapp.Network
+	if name == "" {
+		return networks[0], nil
+	}
+	for _, pn := range networks {
+		if pn.Name == name {
+			return pn, nil
+		}
+	}
+	if network, ok := compose.Networks[name]; ok && network.IsExternal() {
+		return ProjectNetwork{}, fmt.Errorf("x-bootapp.network '%s' is external and cannot be routed", name)
+	}
+	return ProjectNetwork{}, fmt.Errorf("x-bootapp.network '%s' is not a network of this project", name)
+}
+
+// IsExternal reports whether the network is created outside the project
+func (n Network) IsExternal() bool {
+	switch v := n.External.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		// Legacy form: external: {name: ...}
+		return true
+	}
+	return false
+}
+
+// ServiceNetworks returns the networks a service is attached to, sorted
+// Returns nil when the service uses the implicit default network
+func ServiceNetworks(service Service) []string {
+	var names []string
+	switch n := service.Networks.(type) {
+	case []interface{}:
+		for _, item := range n {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case map[string]interface{}:
+		for name := range n {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// serviceStaticIP returns the ipv4_address of a service on a network, if any
+func serviceStaticIP(service Service, network string) string {
+	netMap, ok := service.Networks.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	config, ok := netMap[network].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	ip, _ := config["ipv4_address"].(string)
+	return ip
+}
@@ -0,0 +1,89 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectNetworks(t *testing.T) {
+	compose := &ComposeFile{
+		Services: map[string]Service{
+			"web": {Networks: []interface{}{"frontend", "backend"}},
+			"db": {Networks: map[string]interface{}{
+				"backend": map[string]interface{}{"ipv4_address": "172.25.1.5"},
+			}},
+			"worker": {},
+			"proxy":  {Networks: []interface{}{"shared"}},
+		},
+		Networks: map[string]Network{
+			"frontend": {Name: "custom-frontend", Internal: true},
+			"backend":  {IPAM: &IPAMConfig{Config: []IPAMPoolConfig{{Subnet: "172.25.1.0/24"}}}},
+			"shared":   {External: true},
+			"unused":   {},
+		},
+	}
+
+	networks := ProjectNetworks(compose, "myapp")
+
+	var names []string
+	for _, pn := range networks {
+		names = append(names, pn.Name)
+	}
+	if want := []string{"default", "backend", "frontend"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("ProjectNetworks() names = %v, want %v", names, want)
+	}
+
+	if networks[0].DockerName != "myapp_default" || !reflect.DeepEqual(networks[0].Services, []string{"worker"}) {
+		t.Errorf("default = %+v", networks[0])
+	}
+	backend := networks[1]
+	if backend.Subnet != "172.25.1.0/24" || !reflect.DeepEqual(backend.Services, []string{"db", "web"}) {
+		t.Errorf("backend = %+v", backend)
+	}
+	if backend.StaticIPs["db"] != "172.25.1.5" {
+		t.Errorf("backend.StaticIPs = %v, want db: 172.25.1.5", backend.StaticIPs)
+	}
+	frontend := networks[2]
+	if frontend.DockerName != "custom-frontend" || !frontend.Internal {
+		t.Errorf("frontend = %+v", frontend)
+	}
+}
+
+func TestRoutableNetwork(t *testing.T) {
+	compose := &ComposeFile{
+		Services: map[string]Service{
+			"web":   {Networks: []interface{}{"frontend", "backend", "shared"}},
+			"cache": {},
+		},
+		Networks: map[string]Network{
+			"frontend": {},
+			"backend":  {},
+			"shared":   {External: map[string]interface{}{"name": "proxy"}},
+		},
+	}
+	networks := ProjectNetworks(compose, "myapp")
+
+	tests := []struct {
+		network string
+		want    string
+		wantErr bool
+	}{
+		{"", "default", false},
+		{"frontend", "frontend", false},
+		{"shared", "", true},
+		{"missing", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.network, func(t *testing.T) {
+			compose.XBootapp.Network = tt.network
+			got, err := RoutableNetwork(compose, networks)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoutableNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("RoutableNetwork() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
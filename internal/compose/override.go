@@ -0,0 +1,58 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const overrideHeader = "# Generated by bootapp, do not edit\n"
+
+// ServiceIPOverride is the compose override bootapp generates for the
+// addresses it assigns on the routable network
+type ServiceIPOverride struct {
+	// Network is the routable network's key in the compose file
+	Network string
+	// IPv4 and IPv6 map service name to its address
+	IPv4 map[string]string
+	IPv6 map[string]string
+	// EnableIPv6 sets enable_ipv6 on the network
+	EnableIPv6 bool
+}
+
+// WriteServiceIPOverride writes the override as a compose file, setting each
+// service's ipv4_address/ipv6_address on the network
+func WriteServiceIPOverride(path string, override ServiceIPOverride) error {
+	services := make(map[string]any)
+	setAddress := func(name, key, ip string) {
+		service, ok := services[name].(map[string]any)
+		if !ok {
+			service = map[string]any{"networks": map[string]any{override.Network: map[string]string{}}}
+			services[name] = service
+		}
+		service["networks"].(map[string]any)[override.Network].(map[string]string)[key] = ip
+	}
+	for name, ip := range override.IPv4 {
+		setAddress(name, "ipv4_address", ip)
+	}
+	for name, ip := range override.IPv6 {
+		setAddress(name, "ipv6_address", ip)
+	}
+
+	content := map[string]any{"services": services}
+	if override.EnableIPv6 {
+		content["networks"] = map[string]any{
+			override.Network: map[string]bool{"enable_ipv6": true},
+		}
+	}
+
+	data, err := yaml.Marshal(content)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(overrideHeader), data...), 0644)
+}
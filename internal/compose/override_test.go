@@ -0,0 +1,48 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteServiceIPOverride(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "docker-compose.yml")
+	override := filepath.Join(tmpDir, "overrides", "shop.yml")
+
+	os.WriteFile(base, []byte(`services:
+  web:
+    image: nginx
+  db:
+    image: mysql
+`), 0644)
+
+	ips := map[string]string{"web": "172.18.0.2", "db": "172.18.0.3"}
+	ipv6s := map[string]string{"web": "fd12:3456:789a::ac12:2"}
+	err := WriteServiceIPOverride(override, ServiceIPOverride{Network: "default", IPv4: ips, IPv6: ipv6s, EnableIPv6: true})
+	if err != nil {
+		t.Fatalf("WriteServiceIPOverride() error = %v", err)
+	}
+
+	compose, err := ParseComposeFiles([]string{base, override})
+	if err != nil {
+		t.Fatalf("ParseComposeFiles() error = %v", err)
+	}
+	networks := ProjectNetworks(compose, "shop")
+	if len(networks) != 1 {
+		t.Fatalf("networks = %v, want only default", networks)
+	}
+	if !reflect.DeepEqual(networks[0].StaticIPs, ips) {
+		t.Errorf("StaticIPs = %v, want %v", networks[0].StaticIPs, ips)
+	}
+
+	data, _ := os.ReadFile(override)
+	for _, want := range []string{"ipv6_address: fd12:3456:789a::ac12:2", "enable_ipv6: true"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("override missing %q:\n%s", want, data)
+		}
+	}
+}
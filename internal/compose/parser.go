@@ -4,35 +4,56 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// Environment variable keys that declare domains (in priority order)
+var domainEnvKeys = []string{"DOMAIN", "DOMAINS", "SSL_DOMAIN", "SSL_DOMAINS", "APP_DOMAIN", "VIRTUAL_HOST"}
+
+// Environment variable keys that declare domains needing certificates
+var sslEnvKeys = []string{"SSL_DOMAIN", "SSL_DOMAINS"}
+
 // ComposeFile represents a docker-compose.yml structure
 type ComposeFile struct {
 	Name     string                 `yaml:"name"`
 	Version  string                 `yaml:"version"`
 	Services map[string]Service     `yaml:"services"`
 	Networks map[string]Network     `yaml:"networks"`
+	Include  interface{}            `yaml:"include"`
+	XBootapp ProjectExtension       `yaml:"x-bootapp"`
 	X        map[string]interface{} `yaml:",inline"`
 }
 
 // Service represents a docker-compose service
 type Service struct {
-	Image       string            `yaml:"image"`
-	Build       interface{}       `yaml:"build"`
-	Environment interface{}       `yaml:"environment"`
-	Labels      interface{}       `yaml:"labels"`
-	Networks    interface{}       `yaml:"networks"`
-	Ports       []string          `yaml:"ports"`
-	DependsOn   interface{}       `yaml:"depends_on"`
+	Image      string      `yaml:"image"`
+	Build      interface{} `yaml:"build"`
+	Hostname   string      `yaml:"hostname"`
+	Domainname string      `yaml:"domainname"`
+	// ContainerName is used like Hostname when hostname is not set
+	ContainerName string           `yaml:"container_name"`
+	Environment   interface{}      `yaml:"environment"`
+	EnvFile       interface{}      `yaml:"env_file"`
+	Labels        interface{}      `yaml:"labels"`
+	Networks      interface{}      `yaml:"networks"`
+	Ports         Ports            `yaml:"ports"`
+	DependsOn     interface{}      `yaml:"depends_on"`
+	Extends       interface{}      `yaml:"extends"`
+	Profiles      []string         `yaml:"profiles"`
+	XBootapp      ServiceExtension `yaml:"x-bootapp"`
 }
 
 // Network represents a docker-compose network
 type Network struct {
-	Driver string     `yaml:"driver"`
-	IPAM   *IPAMConfig `yaml:"ipam"`
+	Name       string      `yaml:"name"`
+	Driver     string      `yaml:"driver"`
+	External   interface{} `yaml:"external"`
+	Internal   bool        `yaml:"internal"`
+	Attachable bool        `yaml:"attachable"`
+	IPAM       *IPAMConfig `yaml:"ipam"`
 }
 
 // IPAMConfig represents IPAM configuration
@@ -45,17 +66,25 @@ type IPAMPoolConfig struct {
 	Subnet string `yaml:"subnet"`
 }
 
-// FindComposeFile finds the docker-compose file in the current directory
+// FindComposeFile finds the docker-compose file in the current directory or its parents
 // Returns error if no file found, or multiple files found (use FindComposeFiles for selection)
 func FindComposeFile() (string, error) {
-	files, err := FindComposeFiles()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return FindComposeFileFrom(cwd)
+}
+
+// FindComposeFileFrom is FindComposeFile starting at dir
+func FindComposeFileFrom(dir string) (string, error) {
+	files, err := FindComposeFilesFrom(dir)
 	if err != nil {
 		return "", err
 	}
 
 	if len(files) == 0 {
-		cwd, _ := os.Getwd()
-		return "", fmt.Errorf("no docker-compose file found in %s", cwd)
+		return "", fmt.Errorf("no docker-compose file found in %s or its parent directories", dir)
 	}
 
 	if len(files) == 1 {
@@ -74,8 +103,41 @@ func (e *MultipleFilesError) Error() string {
 	return fmt.Sprintf("multiple compose files found: %v", e.Files)
 }
 
-// FindComposeFiles returns all compose files in the current directory
+// FindComposeFiles returns all compose files in the current directory or,
+// like Compose, the nearest parent directory that has any
 func FindComposeFiles() ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return FindComposeFilesFrom(cwd)
+}
+
+// FindComposeFilesFrom is FindComposeFiles starting at dir
+// The search stops at the repository root (a directory with .git) or the filesystem root
+func FindComposeFilesFrom(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if found := composeFilesIn(dir); len(found) > 0 {
+			return found, nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return nil, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// composeFilesIn returns the compose files in dir, without override files
+func composeFilesIn(dir string) []string {
 	candidates := []string{
 		"docker-compose.yml",
 		"docker-compose.yaml",
@@ -85,20 +147,19 @@ func FindComposeFiles() ([]string, error) {
 		"compose.yaml",
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return nil, err
-	}
-
 	var found []string
 	seen := make(map[string]bool)
 
 	for _, pattern := range candidates {
-		matches, err := filepath.Glob(filepath.Join(cwd, pattern))
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
 		if err != nil {
 			continue
 		}
 		for _, match := range matches {
+			// Override files are loaded together with their base file, not on their own
+			if isOverrideFile(match) {
+				continue
+			}
 			if !seen[match] {
 				seen[match] = true
 				found = append(found, match)
@@ -106,62 +167,238 @@ func FindComposeFiles() ([]string, error) {
 		}
 	}
 
-	return found, nil
+	return found
+}
+
+// OverrideFile returns the override file Compose loads automatically
+// alongside composePath (e.g. docker-compose.override.yml), or "" if none
+// Only the default file names have an override; explicit variants such as
+// docker-compose.local.yml do not
+func OverrideFile(composePath string) string {
+	var candidates []string
+	switch filepath.Base(composePath) {
+	case "docker-compose.yml", "docker-compose.yaml":
+		candidates = []string{"docker-compose.override.yml", "docker-compose.override.yaml"}
+	case "compose.yml", "compose.yaml":
+		candidates = []string{"compose.override.yml", "compose.override.yaml"}
+	default:
+		return ""
+	}
+
+	dir := filepath.Dir(composePath)
+	for _, name := range candidates {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func isOverrideFile(path string) bool {
+	return strings.Contains(filepath.Base(path), ".override.")
 }
 
 // ParseComposeFile parses a docker-compose file
+// Variables are interpolated from the process environment and the .env file
+// next to the compose file, the same way docker compose resolves them
 func ParseComposeFile(path string) (*ComposeFile, error) {
+	return ParseComposeFiles([]string{path})
+}
+
+// ParseComposeFiles parses and merges compose files in order (like repeated -f)
+// The first file's directory is the project directory: .env is read from it
+// and relative paths in every file are resolved against it
+func ParseComposeFiles(paths []string) (*ComposeFile, error) {
+	return ParseComposeFilesInDir(paths, "")
+}
+
+// ParseComposeFilesInDir is ParseComposeFiles with an explicit project
+// directory (like --project-directory); "" uses the first file's directory
+func ParseComposeFilesInDir(paths []string, projectDir string) (*ComposeFile, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files specified")
+	}
+	if projectDir == "" {
+		projectDir = filepath.Dir(paths[0])
+	}
+	env, err := ProjectEnv(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load .env: %w", err)
+	}
+	return parseComposeFiles(paths, projectDir, env)
+}
+
+// ParseComposeFileWithEnv parses a docker-compose file using env for interpolation
+func ParseComposeFileWithEnv(path string, env map[string]string) (*ComposeFile, error) {
+	return ParseComposeFilesWithEnv([]string{path}, env)
+}
+
+// ParseComposeFilesWithEnv parses and merges compose files using env for interpolation
+func ParseComposeFilesWithEnv(paths []string, env map[string]string) (*ComposeFile, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no compose files specified")
+	}
+	return parseComposeFiles(paths, filepath.Dir(paths[0]), env)
+}
+
+func parseComposeFiles(paths []string, projectDir string, env map[string]string) (*ComposeFile, error) {
+	merged, err := loadFiles(paths, env, projectDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveEnvFiles(merged, projectDir, env); err != nil {
+		return nil, err
+	}
+	if err := applyExtensions(merged); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// loadFiles loads each file (resolving include/extends) and merges them in order
+func loadFiles(paths []string, env map[string]string, projectDir string, stack []string) (*ComposeFile, error) {
+	var merged *ComposeFile
+	for _, path := range paths {
+		compose, err := loadFile(path, env, projectDir, stack)
+		if err != nil {
+			if len(paths) > 1 {
+				return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			return nil, err
+		}
+		if merged == nil {
+			merged = compose
+		} else {
+			merged = mergeComposeFiles(merged, compose)
+		}
+	}
+	return merged, nil
+}
+
+// parseSingleFile reads and interpolates one compose file
+func parseSingleFile(path string, env map[string]string) (*ComposeFile, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
 	var compose ComposeFile
-	if err := yaml.Unmarshal(data, &compose); err != nil {
+	if len(root.Content) == 0 {
+		// Empty document
+		return &compose, nil
+	}
+
+	if err := interpolateNode(&root, MapLookup(env)); err != nil {
+		return nil, err
+	}
+	if err := root.Decode(&compose); err != nil {
 		return nil, err
 	}
 
 	return &compose, nil
 }
 
-// GetProjectName derives project name from compose file or directory
-func GetProjectName(composePath string, compose *ComposeFile) string {
-	// Use name from compose file if specified
+// projectNamePattern is the project name format accepted by Compose
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ProjectNameFromDirectory is the source ResolveProjectNameWithSource reports
+// for names derived from the project directory
+const ProjectNameFromDirectory = "directory"
+
+// ResolveProjectName returns the project name as Compose resolves it, in order:
+// the -p flag, COMPOSE_PROJECT_NAME (environment, then .env), the top-level
+// name, and the project directory's basename
+// Explicit names must be valid; the directory name is normalized
+func ResolveProjectName(flagName, projectDir string, compose *ComposeFile) (string, error) {
+	name, _, err := ResolveProjectNameWithSource(flagName, projectDir, compose)
+	return name, err
+}
+
+// ResolveProjectNameWithSource is ResolveProjectName that also returns where
+// the name came from: "-p", "COMPOSE_PROJECT_NAME", "name" or
+// ProjectNameFromDirectory
+func ResolveProjectNameWithSource(flagName, projectDir string, compose *ComposeFile) (string, string, error) {
+	if flagName != "" {
+		name, err := validProjectName(flagName, "-p")
+		return name, "-p", err
+	}
+
+	env, err := ProjectEnv(projectDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load .env: %w", err)
+	}
+	if name := env["COMPOSE_PROJECT_NAME"]; name != "" {
+		name, err := validProjectName(name, "COMPOSE_PROJECT_NAME")
+		return name, "COMPOSE_PROJECT_NAME", err
+	}
+
 	if compose != nil && compose.Name != "" {
-		return compose.Name
+		name, err := validProjectName(compose.Name, "name")
+		return name, "name", err
 	}
-	// Fallback to directory name (lowercased for Docker compatibility)
-	dir := filepath.Dir(composePath)
-	return strings.ToLower(filepath.Base(dir))
+
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", "", err
+	}
+	name := NormalizeProjectName(filepath.Base(absDir))
+	if name == "" {
+		return "", "", fmt.Errorf("cannot derive a project name from directory %q; set 'name:' or use -p", filepath.Base(absDir))
+	}
+	return name, ProjectNameFromDirectory, nil
 }
 
-// ValidateForBootapp checks if compose file is compatible with bootapp
-// Returns error if custom networks or IP assignments are found
-func ValidateForBootapp(compose *ComposeFile) error {
-	// Check for top-level networks definition
-	if len(compose.Networks) > 0 {
-		return fmt.Errorf("custom 'networks:' section detected")
+// NormalizeProjectName lowercases s and drops the characters Compose does not
+// allow in project names, like Compose does for directory names
+func NormalizeProjectName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			b.WriteRune(r)
+		}
 	}
+	return strings.TrimLeft(b.String(), "_-")
+}
 
-	// Check for service-level network config with IP
+func validProjectName(name, source string) (string, error) {
+	if !projectNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid project name %q from %s: must contain only lowercase letters, digits, '-' and '_', and start with a letter or digit", name, source)
+	}
+	return name, nil
+}
+
+// ValidateForBootapp checks if compose file is compatible with bootapp
+// Custom networks and static IPv4 addresses are managed (see ProjectNetworks);
+// returns error for static IPv6 addresses and an unusable x-bootapp.network
+func ValidateForBootapp(compose *ComposeFile) error {
 	for serviceName, service := range compose.Services {
-		if service.Networks != nil {
-			// Check if it's a map with ipv4_address
-			if netMap, ok := service.Networks.(map[string]interface{}); ok {
-				for netName, netConfig := range netMap {
-					if configMap, ok := netConfig.(map[string]interface{}); ok {
-						if _, hasIP := configMap["ipv4_address"]; hasIP {
-							return fmt.Errorf("service '%s' has static IP in network '%s'", serviceName, netName)
-						}
-						if _, hasIP := configMap["ipv6_address"]; hasIP {
-							return fmt.Errorf("service '%s' has static IP in network '%s'", serviceName, netName)
-						}
-					}
+		netMap, ok := service.Networks.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for netName, netConfig := range netMap {
+			if configMap, ok := netConfig.(map[string]interface{}); ok {
+				if _, hasIP := configMap["ipv6_address"]; hasIP {
+					return fmt.Errorf("service '%s' has static IPv6 address in network '%s'", serviceName, netName)
 				}
 			}
 		}
 	}
 
+	if compose.XBootapp.Network != "" {
+		if _, err := RoutableNetwork(compose, ProjectNetworks(compose, "")); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -175,19 +412,13 @@ func ExtractDomain(compose *ComposeFile) string {
 }
 
 // ExtractDomains extracts all domains from compose file environment or labels
-// Supports: DOMAIN, DOMAINS, SSL_DOMAINS, APP_DOMAIN, VIRTUAL_HOST
+// Supports: x-bootapp/bootapp.* declarations, then DOMAIN, DOMAINS, SSL_DOMAINS, APP_DOMAIN, VIRTUAL_HOST
 // All can be comma-separated
 func ExtractDomains(compose *ComposeFile) []string {
 	var allDomains []string
 
 	for _, service := range compose.Services {
-		// Check environment variables
-		domains := extractDomainsFromEnvironment(service.Environment)
-		allDomains = append(allDomains, domains...)
-
-		// Check labels (for Traefik, etc.)
-		domains = extractDomainsFromLabels(service.Labels)
-		allDomains = append(allDomains, domains...)
+		allDomains = append(allDomains, serviceDomains(service)...)
 	}
 
 	// Remove duplicates
@@ -196,32 +427,84 @@ func ExtractDomains(compose *ComposeFile) []string {
 
 // ExtractServiceDomains extracts domains per service
 // Returns map[serviceName][]domains
-// Only services with DOMAIN/DOMAINS/SSL_DOMAINS/APP_DOMAIN/VIRTUAL_HOST will have entries
+// Only services with declared domains or DOMAIN/DOMAINS/SSL_DOMAINS/APP_DOMAIN/VIRTUAL_HOST will have entries
 func ExtractServiceDomains(compose *ComposeFile) map[string][]string {
 	result := make(map[string][]string)
 
 	for serviceName, service := range compose.Services {
+		if domains := serviceDomains(service); len(domains) > 0 {
+			result[serviceName] = uniqueDomains(domains)
+		}
+	}
+
+	return result
+}
+
+// serviceDomains returns the domains of a service
+// Domains declared via x-bootapp or bootapp.* labels take precedence over
+// the environment and Traefik label heuristics; opted-out services have none
+func serviceDomains(service Service) []string {
+	ext := service.XBootapp
+	if ext.Disabled() {
+		return nil
+	}
+	if ext.Declared() {
 		var domains []string
+		domains = append(domains, ext.Domains...)
+		return append(domains, ext.SSLDomains...)
+	}
 
-		// Check environment variables
-		domains = append(domains, extractDomainsFromEnvironment(service.Environment)...)
+	// Check environment variables
+	domains := extractDomainsFromEnvironment(service.Environment)
 
-		// Check labels (for Traefik, etc.)
-		domains = append(domains, extractDomainsFromLabels(service.Labels)...)
+	// Check labels (for Traefik, etc.)
+	domains = append(domains, extractDomainsFromLabels(service.Labels)...)
 
-		if len(domains) > 0 {
-			result[serviceName] = uniqueDomains(domains)
+	if ext.HostnamesEnabled() {
+		if domain := hostnameDomain(service); domain != "" {
+			domains = append(domains, domain)
 		}
 	}
+	return domains
+}
+
+// hostnameDomain builds a domain from the compose naming fields:
+// hostname (or container_name) joined with domainname, e.g.
+// hostname: api + domainname: shop.test -> api.shop.test
+// Without domainname, only a name that already contains a dot is a domain
+func hostnameDomain(service Service) string {
+	name := service.Hostname
+	if name == "" {
+		name = service.ContainerName
+	}
+	if name == "" {
+		return ""
+	}
+	if service.Domainname != "" {
+		return name + "." + service.Domainname
+	}
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return ""
+}
 
-	return result
+// serviceSSLDomains returns the domains of a service that need certificates
+func serviceSSLDomains(service Service) []string {
+	ext := service.XBootapp
+	if ext.Disabled() {
+		return nil
+	}
+	if ext.Declared() {
+		return ext.SSLDomains
+	}
+	return extractSSLDomainsFromEnvironment(service.Environment)
 }
 
 func extractDomainsFromEnvironment(env interface{}) []string {
 	var domains []string
 
-	// Environment variable keys to check (in priority order)
-	envKeys := []string{"DOMAIN", "DOMAINS", "SSL_DOMAIN", "SSL_DOMAINS", "APP_DOMAIN", "VIRTUAL_HOST"}
+	envKeys := domainEnvKeys
 
 	switch e := env.(type) {
 	case []interface{}:
@@ -275,24 +558,37 @@ func uniqueDomains(domains []string) []string {
 	return result
 }
 
-// ExtractSSLDomains extracts only SSL_DOMAINS from compose file
+// ExtractSSLDomains extracts only SSL domains (ssl_domains or SSL_DOMAIN/SSL_DOMAINS) from compose file
 // Returns all unique SSL domains that need certificates
 func ExtractSSLDomains(compose *ComposeFile) []string {
 	var allDomains []string
 
 	for _, service := range compose.Services {
-		domains := extractSSLDomainsFromEnvironment(service.Environment)
-		allDomains = append(allDomains, domains...)
+		allDomains = append(allDomains, serviceSSLDomains(service)...)
 	}
 
 	return uniqueDomains(allDomains)
 }
 
+// ExtractServiceSSLDomains extracts SSL domains per service
+// Returns map[serviceName][]domains
+func ExtractServiceSSLDomains(compose *ComposeFile) map[string][]string {
+	result := make(map[string][]string)
+
+	for serviceName, service := range compose.Services {
+		if domains := serviceSSLDomains(service); len(domains) > 0 {
+			result[serviceName] = uniqueDomains(domains)
+		}
+	}
+
+	return result
+}
+
 // extractSSLDomainsFromEnvironment extracts SSL_DOMAIN/SSL_DOMAINS from environment
 func extractSSLDomainsFromEnvironment(env interface{}) []string {
 	var domains []string
 
-	envKeys := []string{"SSL_DOMAIN", "SSL_DOMAINS"}
+	envKeys := sslEnvKeys
 
 	switch e := env.(type) {
 	case []interface{}:
@@ -317,87 +613,3 @@ func extractSSLDomainsFromEnvironment(env interface{}) []string {
 
 	return domains
 }
-
-func extractDomainsFromLabels(labels interface{}) []string {
-	var domains []string
-
-	switch l := labels.(type) {
-	case []interface{}:
-		for _, item := range l {
-			if str, ok := item.(string); ok {
-				// Traefik rule
-				if strings.Contains(str, "traefik.http.routers") && strings.Contains(str, "Host(") {
-					domains = append(domains, extractTraefikHosts(str)...)
-				}
-			}
-		}
-	case map[string]interface{}:
-		for key, value := range l {
-			if strings.Contains(key, "traefik.http.routers") && strings.Contains(key, "rule") {
-				if str, ok := value.(string); ok && strings.Contains(str, "Host(") {
-					domains = append(domains, extractTraefikHosts(str)...)
-				}
-			}
-		}
-	}
-
-	return domains
-}
-
-// extractTraefikHosts extracts hosts from Traefik Host() rule
-// Supports: Host(`a.com`) || Host(`b.com`) and Host(`a.com`, `b.com`)
-func extractTraefikHosts(rule string) []string {
-	var hosts []string
-	remaining := rule
-
-	for {
-		// Find Host( - supports both Host(` and Host(
-		start := strings.Index(remaining, "Host(")
-		if start == -1 {
-			break
-		}
-		start += 5 // Move past "Host("
-
-		// Find the closing )
-		depth := 1
-		end := start
-		for i := start; i < len(remaining) && depth > 0; i++ {
-			if remaining[i] == '(' {
-				depth++
-			} else if remaining[i] == ')' {
-				depth--
-			}
-			if depth == 0 {
-				end = i
-			}
-		}
-
-		if end <= start {
-			break
-		}
-
-		// Extract content inside Host()
-		content := remaining[start:end]
-
-		// Parse backtick-quoted domains: `domain1`, `domain2`
-		for {
-			tickStart := strings.Index(content, "`")
-			if tickStart == -1 {
-				break
-			}
-			tickEnd := strings.Index(content[tickStart+1:], "`")
-			if tickEnd == -1 {
-				break
-			}
-			host := content[tickStart+1 : tickStart+1+tickEnd]
-			if host != "" {
-				hosts = append(hosts, host)
-			}
-			content = content[tickStart+1+tickEnd+1:]
-		}
-
-		remaining = remaining[end:]
-	}
-
-	return hosts
-}
@@ -3,29 +3,78 @@ package compose
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
-func TestGetProjectName(t *testing.T) {
+func TestResolveProjectName(t *testing.T) {
 	tests := []struct {
-		name        string
-		composePath string
-		compose     *ComposeFile
-		expected    string
+		name     string
+		dir      string
+		flag     string
+		env      string
+		dotEnv   string
+		compose  *ComposeFile
+		expected string
+		source   string
+		wantErr  bool
 	}{
-		{"from compose name", "/home/user/MyProject/docker-compose.yml", &ComposeFile{Name: "myproject"}, "myproject"},
-		{"fallback to dir lowercase", "/home/user/MyProject/docker-compose.yml", nil, "myproject"},
-		{"simple path", "/home/user/myproject/docker-compose.yml", nil, "myproject"},
-		{"nested path", "/var/www/apps/webapp/docker-compose.yml", nil, "webapp"},
-		{"current dir", "./docker-compose.yml", nil, "."},
-		{"compose.yaml", "/projects/api/compose.yaml", nil, "api"},
+		{name: "directory basename", dir: "myproject", expected: "myproject", source: ProjectNameFromDirectory},
+		{name: "directory normalized", dir: "My App.v2", expected: "myappv2"},
+		{name: "directory leading separators", dir: "_-web", expected: "web"},
+		{name: "directory without valid characters", dir: "...", wantErr: true},
+		{name: "compose name", dir: "myproject", compose: &ComposeFile{Name: "shop"}, expected: "shop", source: "name"},
+		{name: "invalid compose name", dir: "myproject", compose: &ComposeFile{Name: "My Shop"}, wantErr: true},
+		{name: "dotenv over compose name", dir: "myproject", dotEnv: "COMPOSE_PROJECT_NAME=fromdotenv\n", compose: &ComposeFile{Name: "shop"}, expected: "fromdotenv", source: "COMPOSE_PROJECT_NAME"},
+		{name: "environment over dotenv", dir: "myproject", env: "fromenv", dotEnv: "COMPOSE_PROJECT_NAME=fromdotenv\n", expected: "fromenv"},
+		{name: "flag over environment", dir: "myproject", flag: "fromflag", env: "fromenv", expected: "fromflag", source: "-p"},
+		{name: "invalid flag", dir: "myproject", flag: "-bad", wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GetProjectName(tt.composePath, tt.compose)
+			t.Setenv("COMPOSE_PROJECT_NAME", tt.env)
+			if tt.env == "" {
+				os.Unsetenv("COMPOSE_PROJECT_NAME")
+			}
+			dir := filepath.Join(t.TempDir(), tt.dir)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.dotEnv != "" {
+				writeFiles(t, dir, map[string]string{".env": tt.dotEnv})
+			}
+
+			result, source, err := ResolveProjectNameWithSource(tt.flag, dir, tt.compose)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveProjectName() error = %v, wantErr %v", err, tt.wantErr)
+			}
 			if result != tt.expected {
-				t.Errorf("GetProjectName(%q) = %q, want %q", tt.composePath, result, tt.expected)
+				t.Errorf("ResolveProjectName() = %q, want %q", result, tt.expected)
+			}
+			if tt.source != "" && source != tt.source {
+				t.Errorf("source = %q, want %q", source, tt.source)
+			}
+		})
+	}
+}
+
+func TestNormalizeProjectName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MyProject", "myproject"},
+		{"My App.v2", "myappv2"},
+		{"my_app-1", "my_app-1"},
+		{"--app", "app"},
+		{"café", "caf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := NormalizeProjectName(tt.input); got != tt.expected {
+				t.Errorf("NormalizeProjectName(%q) = %q, want %q", tt.input, got, tt.expected)
 			}
 		})
 	}
@@ -477,6 +526,46 @@ func TestFindComposeFiles(t *testing.T) {
 	}
 }
 
+func TestFindComposeFilesFrom_Parents(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": "services: {}\n",
+		"repo/.git/HEAD":     "ref: refs/heads/main\n",
+		"repo/compose.yaml":  "services: {}\n",
+		"repo/src/app/.keep": "",
+		"repo/sub/.git":      "gitdir: ../.git/modules/sub\n",
+		"repo/sub/pkg/.keep": "",
+	})
+
+	tests := []struct {
+		name  string
+		start string
+		want  string
+	}{
+		{"current directory", "repo", filepath.Join(tmpDir, "repo", "compose.yaml")},
+		{"parent directory", "repo/src/app", filepath.Join(tmpDir, "repo", "compose.yaml")},
+		{"stops at repository root", "repo/sub/pkg", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := FindComposeFilesFrom(filepath.Join(tmpDir, tt.start))
+			if err != nil {
+				t.Fatalf("FindComposeFilesFrom() error = %v", err)
+			}
+			if tt.want == "" {
+				if len(found) != 0 {
+					t.Errorf("FindComposeFilesFrom() = %v, want none", found)
+				}
+				return
+			}
+			if len(found) != 1 || found[0] != tt.want {
+				t.Errorf("FindComposeFilesFrom() = %v, want [%s]", found, tt.want)
+			}
+		})
+	}
+}
+
 func TestFindComposeFile_MultipleError(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "compose-test-*")
 	if err != nil {
@@ -620,7 +709,7 @@ func TestValidateForBootapp_Valid(t *testing.T) {
 func TestValidateForBootapp_CustomNetworks(t *testing.T) {
 	compose := &ComposeFile{
 		Services: map[string]Service{
-			"app": {Image: "nginx"},
+			"app": {Image: "nginx", Networks: []interface{}{"custom"}},
 		},
 		Networks: map[string]Network{
 			"custom": {Driver: "bridge"},
@@ -628,28 +717,46 @@ func TestValidateForBootapp_CustomNetworks(t *testing.T) {
 	}
 
 	err := ValidateForBootapp(compose)
-	if err == nil {
-		t.Error("ValidateForBootapp() should return error for custom networks")
+	if err != nil {
+		t.Errorf("ValidateForBootapp() error = %v, want nil for custom networks", err)
+	}
+
+	compose.XBootapp.Network = "missing"
+	if err := ValidateForBootapp(compose); err == nil {
+		t.Error("ValidateForBootapp() should return error for an unknown x-bootapp.network")
 	}
 }
 
 func TestValidateForBootapp_StaticIP(t *testing.T) {
-	compose := &ComposeFile{
-		Services: map[string]Service{
-			"app": {
-				Image: "nginx",
-				Networks: map[string]interface{}{
-					"default": map[string]interface{}{
-						"ipv4_address": "172.18.0.100",
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"ipv4", "ipv4_address", false},
+		{"ipv6", "ipv6_address", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compose := &ComposeFile{
+				Services: map[string]Service{
+					"app": {
+						Image: "nginx",
+						Networks: map[string]interface{}{
+							"default": map[string]interface{}{
+								tt.key: "172.18.0.100",
+							},
+						},
 					},
 				},
-			},
-		},
-	}
+			}
 
-	err := ValidateForBootapp(compose)
-	if err == nil {
-		t.Error("ValidateForBootapp() should return error for static IP")
+			err := ValidateForBootapp(compose)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateForBootapp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
 	}
 }
 
@@ -675,3 +782,95 @@ func TestExtractServiceDomains_MixedEnvAndLabels(t *testing.T) {
 		t.Errorf("app domains = %v, want 2 domains (from env and labels)", domains)
 	}
 }
+
+func TestParseComposeFile_CertLayouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	content := `services:
+  web:
+    image: nginx
+    environment:
+      SSL_DOMAINS: myapp.test,www.myapp.test
+    x-bootapp:
+      certs:
+        nginx: ./docker/nginx/certs
+        java: ./docker/java
+  db:
+    image: mysql:8
+`
+	if err := os.WriteFile(composePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write compose file: %v", err)
+	}
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	certs := compose.Services["web"].XBootapp.Certs
+	if certs["nginx"] != "./docker/nginx/certs" || certs["java"] != "./docker/java" {
+		t.Errorf("web x-bootapp.certs = %v", certs)
+	}
+	if len(compose.Services["db"].XBootapp.Certs) != 0 {
+		t.Errorf("db x-bootapp.certs = %v, want empty", compose.Services["db"].XBootapp.Certs)
+	}
+
+	sslDomains := ExtractServiceSSLDomains(compose)
+	if len(sslDomains) != 1 || len(sslDomains["web"]) != 2 {
+		t.Errorf("ExtractServiceSSLDomains() = %v", sslDomains)
+	}
+}
+
+func TestExtractServiceDomains_Hostnames(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFiles(t, tmpDir, map[string]string{
+		"docker-compose.yml": `x-bootapp:
+  hostnames: true
+services:
+  api:
+    hostname: api
+    domainname: shop.test
+  web:
+    container_name: web.shop.test
+  admin:
+    container_name: shop-admin
+    domainname: shop.test
+  worker:
+    hostname: worker
+  db:
+    hostname: db.shop.test
+    labels:
+      bootapp.hostnames: "false"
+  app:
+    hostname: ignored.shop.test
+    x-bootapp:
+      domains: [app.shop.test]
+`,
+	})
+
+	compose, err := ParseComposeFile(filepath.Join(tmpDir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	result := ExtractServiceDomains(compose)
+	expected := map[string][]string{
+		"api":   {"api.shop.test"},
+		"web":   {"web.shop.test"},
+		"admin": {"shop-admin.shop.test"},
+		"app":   {"app.shop.test"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ExtractServiceDomains() = %v, want %v", result, expected)
+	}
+
+	// Disabled by default
+	compose.XBootapp.Hostnames = nil
+	for name, service := range compose.Services {
+		service.XBootapp.Hostnames = nil
+		compose.Services[name] = service
+	}
+	if result := ExtractServiceDomains(compose); len(result) != 1 {
+		t.Errorf("ExtractServiceDomains() = %v, want only app without hostnames", result)
+	}
+}
@@ -0,0 +1,236 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Port is a service port in either compose syntax
+//
+//	ports:
+//	  - "8080:80"
+//	  - "127.0.0.1:5353:53/udp"
+//	  - target: 443
+//	    published: "8443"
+type Port struct {
+	Target int `yaml:"target"`
+	// Published is the host port or range ("8000-8010"), empty if not published
+	Published string `yaml:"published"`
+	HostIP    string `yaml:"host_ip"`
+	// Protocol is "tcp" unless set
+	Protocol    string `yaml:"protocol"`
+	Mode        string `yaml:"mode"`
+	Name        string `yaml:"name"`
+	AppProtocol string `yaml:"app_protocol"`
+}
+
+// Ports is a list of service ports; short syntax ranges expand to one Port each
+type Ports []Port
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (p *Ports) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: ports must be a list", node.Line)
+	}
+
+	var ports Ports
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			parsed, err := ParsePort(item.Value)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", item.Line, err)
+			}
+			ports = append(ports, parsed...)
+		case yaml.MappingNode:
+			var port Port
+			if err := item.Decode(&port); err != nil {
+				return err
+			}
+			if port.Target <= 0 || port.Target > 65535 {
+				return fmt.Errorf("line %d: port target must be between 1 and 65535", item.Line)
+			}
+			if port.Protocol == "" {
+				port.Protocol = "tcp"
+			}
+			ports = append(ports, port)
+		default:
+			return fmt.Errorf("line %d: invalid port entry", item.Line)
+		}
+	}
+	*p = ports
+	return nil
+}
+
+// ParsePort parses the short port syntax [[host_ip:]published:]target[/protocol]
+// Ranges expand into one Port per target port; a single target with a
+// published range keeps the range, as Docker picks a free host port from it
+func ParsePort(spec string) ([]Port, error) {
+	rest, protocol := spec, "tcp"
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		rest, protocol = rest[:i], rest[i+1:]
+		if protocol != "tcp" && protocol != "udp" && protocol != "sctp" {
+			return nil, fmt.Errorf("port %q: unknown protocol %q", spec, protocol)
+		}
+	}
+
+	var hostIP, published, target string
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		published, target = rest[:i], rest[i+1:]
+		if j := strings.LastIndex(published, ":"); j >= 0 {
+			hostIP, published = published[:j], published[j+1:]
+			hostIP = strings.TrimSuffix(strings.TrimPrefix(hostIP, "["), "]")
+			if hostIP == "" {
+				return nil, fmt.Errorf("port %q: empty host IP", spec)
+			}
+		}
+	} else {
+		target = rest
+	}
+
+	targetLow, targetHigh, err := parsePortRange(target)
+	if err != nil {
+		return nil, fmt.Errorf("port %q: %w", spec, err)
+	}
+	var publishedLow, publishedHigh int
+	if published != "" {
+		if publishedLow, publishedHigh, err = parsePortRange(published); err != nil {
+			return nil, fmt.Errorf("port %q: %w", spec, err)
+		}
+	}
+
+	count := targetHigh - targetLow + 1
+	if published != "" && count > 1 && publishedHigh-publishedLow+1 != count {
+		return nil, fmt.Errorf("port %q: published and target ranges differ in size", spec)
+	}
+
+	ports := make([]Port, 0, count)
+	for i := 0; i < count; i++ {
+		port := Port{Target: targetLow + i, HostIP: hostIP, Protocol: protocol}
+		switch {
+		case published == "":
+		case count > 1:
+			port.Published = strconv.Itoa(publishedLow + i)
+		default:
+			port.Published = published
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// parsePortRange parses "80" or "8000-8010"
+func parsePortRange(s string) (int, int, error) {
+	low, high := s, s
+	if i := strings.Index(s, "-"); i >= 0 {
+		low, high = s[:i], s[i+1:]
+	}
+	lo, err := strconv.Atoi(low)
+	if err != nil || lo <= 0 || lo > 65535 {
+		return 0, 0, fmt.Errorf("invalid port %q", s)
+	}
+	hi, err := strconv.Atoi(high)
+	if err != nil || hi < lo || hi > 65535 {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return lo, hi, nil
+}
+
+// String returns the port in short syntax
+func (p Port) String() string {
+	s := strconv.Itoa(p.Target)
+	if p.Published != "" {
+		s = p.Published + ":" + s
+		if p.HostIP != "" {
+			host := p.HostIP
+			if strings.Contains(host, ":") {
+				host = "[" + host + "]"
+			}
+			s = host + ":" + s
+		}
+	}
+	if p.Protocol != "" && p.Protocol != "tcp" {
+		s += "/" + p.Protocol
+	}
+	return s
+}
+
+// ServiceURLs returns the URLs of each service, sorted:
+// one per domain, reached on the container IP, and one per published TCP port
+// Domains listed in SSL_DOMAINS use https; a port is added when the service
+// publishes ports but not the scheme's default one
+func ServiceURLs(compose *ComposeFile) map[string][]string {
+	result := make(map[string][]string)
+	sslDomains := ExtractServiceSSLDomains(compose)
+
+	for name, service := range compose.Services {
+		ssl := make(map[string]bool)
+		for _, domain := range sslDomains[name] {
+			ssl[domain] = true
+		}
+
+		var urls []string
+		for _, domain := range uniqueDomains(serviceDomains(service)) {
+			scheme := "http"
+			if ssl[domain] {
+				scheme = "https"
+			}
+			urls = append(urls, scheme+"://"+domain+containerPortSuffix(service.Ports, scheme))
+		}
+
+		for _, port := range service.Ports {
+			if port.Protocol != "tcp" || port.Published == "" || strings.Contains(port.Published, "-") {
+				continue
+			}
+			host := port.HostIP
+			switch {
+			case host == "" || host == "0.0.0.0" || host == "::":
+				host = "localhost"
+			case strings.Contains(host, ":"):
+				host = "[" + host + "]"
+			}
+			scheme := "http"
+			if port.Target == 443 && len(sslDomains[name]) > 0 {
+				scheme = "https"
+			}
+			urls = append(urls, fmt.Sprintf("%s://%s:%s", scheme, host, port.Published))
+		}
+
+		if len(urls) > 0 {
+			sort.Strings(urls)
+			result[name] = urls
+		}
+	}
+
+	return result
+}
+
+// containerPortSuffix returns ":<port>" for a domain URL when the service's
+// TCP ports do not include the scheme's default port
+func containerPortSuffix(ports Ports, scheme string) string {
+	defaultPort := 80
+	if scheme == "https" {
+		defaultPort = 443
+	}
+
+	first := 0
+	for _, port := range ports {
+		if port.Protocol != "tcp" {
+			continue
+		}
+		if port.Target == defaultPort {
+			return ""
+		}
+		if first == 0 {
+			first = port.Target
+		}
+	}
+	if first == 0 {
+		return ""
+	}
+	return ":" + strconv.Itoa(first)
+}
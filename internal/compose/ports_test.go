@@ -0,0 +1,111 @@
+package compose
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []Port
+		wantErr bool
+	}{
+		{"80", []Port{{Target: 80, Protocol: "tcp"}}, false},
+		{"8080:80", []Port{{Target: 80, Published: "8080", Protocol: "tcp"}}, false},
+		{"127.0.0.1:5353:53/udp", []Port{{Target: 53, Published: "5353", HostIP: "127.0.0.1", Protocol: "udp"}}, false},
+		{"[::1]:8080:80", []Port{{Target: 80, Published: "8080", HostIP: "::1", Protocol: "tcp"}}, false},
+		{"8000-8001:80-81", []Port{
+			{Target: 80, Published: "8000", Protocol: "tcp"},
+			{Target: 81, Published: "8001", Protocol: "tcp"},
+		}, false},
+		{"3000-3001", []Port{{Target: 3000, Protocol: "tcp"}, {Target: 3001, Protocol: "tcp"}}, false},
+		{"9000-9010:80", []Port{{Target: 80, Published: "9000-9010", Protocol: "tcp"}}, false},
+		{"8000-8002:80-81", nil, true},
+		{"80/http", nil, true},
+		{"abc", nil, true},
+		{"70000", nil, true},
+		{":8080:80", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParsePort(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPorts_UnmarshalYAML(t *testing.T) {
+	content := `
+- 80
+- "127.0.0.1:8443:443"
+- target: 5432
+  published: 15432
+  host_ip: 127.0.0.1
+- target: 53
+  protocol: udp
+  mode: host
+`
+	var ports Ports
+	if err := yaml.Unmarshal([]byte(content), &ports); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	var got []string
+	for _, port := range ports {
+		got = append(got, port.String())
+	}
+	want := []string{"80", "127.0.0.1:8443:443", "127.0.0.1:15432:5432", "53/udp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ports = %v, want %v", got, want)
+	}
+	if ports[3].Mode != "host" {
+		t.Errorf("Mode = %q, want host", ports[3].Mode)
+	}
+
+	if err := yaml.Unmarshal([]byte("- published: 8080\n"), &ports); err == nil {
+		t.Error("Unmarshal() should fail without a target")
+	}
+}
+
+func TestServiceURLs(t *testing.T) {
+	compose := &ComposeFile{
+		Services: map[string]Service{
+			"web": {
+				Environment: map[string]interface{}{"DOMAIN": "app.test", "SSL_DOMAINS": "app.test"},
+				Ports:       Ports{{Target: 443, Published: "8443", Protocol: "tcp"}},
+			},
+			"api": {
+				Environment: map[string]interface{}{"DOMAIN": "api.test"},
+				Ports: Ports{
+					{Target: 3000, Published: "3000", HostIP: "127.0.0.1", Protocol: "tcp"},
+					{Target: 53, Published: "5353", Protocol: "udp"},
+				},
+			},
+			"docs": {
+				Environment: map[string]interface{}{"DOMAIN": "docs.test"},
+			},
+			"db": {
+				Ports: Ports{{Target: 5432, Protocol: "tcp"}},
+			},
+		},
+	}
+
+	got := ServiceURLs(compose)
+	want := map[string][]string{
+		"web":  {"https://app.test", "https://localhost:8443"},
+		"api":  {"http://127.0.0.1:3000", "http://api.test:3000"},
+		"docs": {"http://docs.test"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceURLs() = %v, want %v", got, want)
+	}
+}
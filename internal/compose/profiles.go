@@ -0,0 +1,98 @@
+package compose
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// ActiveProfiles returns the enabled profiles: --profile flags plus COMPOSE_PROFILES
+func ActiveProfiles(flagProfiles []string) []string {
+	var profiles []string
+	seen := make(map[string]bool)
+
+	add := func(p string) {
+		p = strings.TrimSpace(p)
+		if p != "" && !seen[p] {
+			seen[p] = true
+			profiles = append(profiles, p)
+		}
+	}
+
+	for _, p := range flagProfiles {
+		add(p)
+	}
+	for _, p := range strings.Split(os.Getenv("COMPOSE_PROFILES"), ",") {
+		add(p)
+	}
+	return profiles
+}
+
+// IsActive reports whether a service runs with the given profiles
+// Services without profiles are always active; "*" enables every profile
+func (s Service) IsActive(profiles []string) bool {
+	if len(s.Profiles) == 0 {
+		return true
+	}
+	for _, enabled := range profiles {
+		if enabled == "*" {
+			return true
+		}
+		for _, p := range s.Profiles {
+			if p == enabled {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterActiveServices removes services that Compose would not start
+// Services named in targets, and everything they depend on directly or
+// indirectly, are kept regardless of profiles, as with
+// "docker compose up <service>"
+// Returns the names of the removed services, sorted
+func FilterActiveServices(compose *ComposeFile, profiles []string, targets []string) []string {
+	targeted := make(map[string]bool)
+	queue := append([]string(nil), targets...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if targeted[name] {
+			continue
+		}
+		targeted[name] = true
+		queue = append(queue, dependencyNames(compose.Services[name].DependsOn)...)
+	}
+
+	var removed []string
+	for name, service := range compose.Services {
+		if targeted[name] || service.IsActive(profiles) {
+			continue
+		}
+		delete(compose.Services, name)
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// dependencyNames returns the services in a depends_on list or mapping
+func dependencyNames(dependsOn interface{}) []string {
+	var names []string
+	switch deps := dependsOn.(type) {
+	case []interface{}:
+		for _, dep := range deps {
+			if name, ok := dep.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case []string:
+		names = append(names, deps...)
+	case map[string]interface{}:
+		for name := range deps {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,138 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestActiveProfiles(t *testing.T) {
+	t.Setenv("COMPOSE_PROFILES", "debug, tools,")
+
+	profiles := ActiveProfiles([]string{"mail", "debug"})
+	expected := []string{"mail", "debug", "tools"}
+	if len(profiles) != len(expected) {
+		t.Fatalf("ActiveProfiles() = %v, want %v", profiles, expected)
+	}
+	for i, p := range expected {
+		if profiles[i] != p {
+			t.Errorf("ActiveProfiles()[%d] = %q, want %q", i, profiles[i], p)
+		}
+	}
+}
+
+func TestService_IsActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  Service
+		profiles []string
+		expected bool
+	}{
+		{"no profiles always active", Service{}, nil, true},
+		{"profile not enabled", Service{Profiles: []string{"mail"}}, nil, false},
+		{"profile enabled", Service{Profiles: []string{"mail"}}, []string{"mail"}, true},
+		{"one of many", Service{Profiles: []string{"debug", "tools"}}, []string{"tools"}, true},
+		{"wildcard", Service{Profiles: []string{"mail"}}, []string{"*"}, true},
+		{"other profile", Service{Profiles: []string{"mail"}}, []string{"debug"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.service.IsActive(tt.profiles); result != tt.expected {
+				t.Errorf("IsActive(%v) = %v, want %v", tt.profiles, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterActiveServices(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	content := `services:
+  app:
+    environment:
+      SSL_DOMAINS: app.test
+  mailhog:
+    profiles: [mail]
+    environment:
+      VIRTUAL_HOST: mail.test
+  kibana:
+    profiles: [debug]
+    environment:
+      SSL_DOMAINS: kibana.test
+`
+	os.WriteFile(composePath, []byte(content), 0644)
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	removed := FilterActiveServices(compose, []string{"mail"}, nil)
+	if len(removed) != 1 || removed[0] != "kibana" {
+		t.Errorf("FilterActiveServices() removed = %v, want [kibana]", removed)
+	}
+
+	serviceDomains := ExtractServiceDomains(compose)
+	if _, ok := serviceDomains["mailhog"]; !ok {
+		t.Error("mailhog should be active with the mail profile")
+	}
+	if _, ok := serviceDomains["kibana"]; ok {
+		t.Error("kibana should not get domains without the debug profile")
+	}
+	if ssl := ExtractSSLDomains(compose); len(ssl) != 1 || ssl[0] != "app.test" {
+		t.Errorf("SSL domains = %v, want [app.test]", ssl)
+	}
+}
+
+func TestFilterActiveServices_Targeted(t *testing.T) {
+	compose := &ComposeFile{Services: map[string]Service{
+		"app":    {},
+		"kibana": {Profiles: []string{"debug"}},
+	}}
+
+	// Explicitly named services start regardless of profiles
+	removed := FilterActiveServices(compose, nil, []string{"kibana"})
+	if len(removed) != 0 {
+		t.Errorf("FilterActiveServices() removed = %v, want none", removed)
+	}
+	if len(compose.Services) != 2 {
+		t.Errorf("Services count = %d, want 2", len(compose.Services))
+	}
+}
+
+func TestFilterActiveServices_TargetDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	composePath := filepath.Join(tmpDir, "docker-compose.yml")
+	content := `services:
+  web:
+    profiles: [frontend]
+    depends_on: [api]
+  api:
+    profiles: [backend]
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    profiles: [backend]
+  kibana:
+    profiles: [debug]
+`
+	os.WriteFile(composePath, []byte(content), 0644)
+
+	compose, err := ParseComposeFile(composePath)
+	if err != nil {
+		t.Fatalf("ParseComposeFile() error = %v", err)
+	}
+
+	// web needs api (list form), which needs db (mapping form)
+	removed := FilterActiveServices(compose, nil, []string{"web"})
+	if len(removed) != 1 || removed[0] != "kibana" {
+		t.Errorf("FilterActiveServices() removed = %v, want [kibana]", removed)
+	}
+	for _, name := range []string{"web", "api", "db"} {
+		if _, ok := compose.Services[name]; !ok {
+			t.Errorf("%s should be kept as a dependency of web", name)
+		}
+	}
+}
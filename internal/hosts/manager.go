@@ -43,7 +43,9 @@ func AddEntries(containers map[string]network.ContainerInfo, projectName string)
 	}
 
 	// Build all entries (comment line + host entry for each domain)
-	// Add both IPv4 and IPv6 (IPv4-mapped) to prevent IPv6 DNS bypass
+	// Add both IPv4 and IPv6 to prevent IPv6 DNS bypass: the container's own
+	// address (AAAA) when it is set, else the IPv4-mapped one
+	// Callers leave IPv6 empty when the host cannot reach it
 	var entries []string
 	commentLine := fmt.Sprintf("%s:%s", marker, projectName)
 	for _, info := range containers {
@@ -52,8 +54,11 @@ func AddEntries(containers map[string]network.ContainerInfo, projectName string)
 		}
 		for _, domain := range info.Domains {
 			if domain != "" {
-				// IPv6 (IPv4-mapped address) - prevents macOS IPv6 DNS bypass
-				ipv6 := "::ffff:" + info.IP
+				// IPv6 - prevents macOS IPv6 DNS bypass
+				ipv6 := info.IPv6
+				if ipv6 == "" {
+					ipv6 = "::ffff:" + info.IP
+				}
 				entries = append(entries, commentLine)
 				entries = append(entries, fmt.Sprintf("%s\t%s", ipv6, domain))
 				// IPv4
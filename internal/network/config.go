@@ -0,0 +1,149 @@
+package network
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+)
+
+const (
+	userConfigFile = "config.json"
+	// Project subnets must leave room for /28 network sub-ranges
+	maxProjectPrefix = maxSubRangeBits - 1
+)
+
+// DefaultSubnetPools covers 172.18.0.0/16 to 172.31.0.0/16, clear of Docker's
+// default bridge (172.17.0.0/16)
+var DefaultSubnetPools = []string{"172.18.0.0/15", "172.20.0.0/14", "172.24.0.0/13"}
+
+// DefaultSubnetPrefix is the size of a project subnet
+const DefaultSubnetPrefix = 16
+
+// projectPrefix6 is the size of an IPv6 project subnet
+const projectPrefix6 = 64
+
+// ulaRange holds locally assigned unique local addresses
+var ulaRange = netip.MustParsePrefix("fd00::/8")
+
+// Config is the user configuration in ~/.bootapp/config.json
+//
+//	{
+//	  "subnet_pools": ["10.210.0.0/16"],
+//	  "subnet_prefix": 24
+//	}
+type Config struct {
+	// SubnetPools are the CIDR ranges project subnets are allocated from, in order
+	SubnetPools []string `json:"subnet_pools,omitempty"`
+	// SubnetPrefix is the prefix length of each project subnet
+	SubnetPrefix int `json:"subnet_prefix,omitempty"`
+	// ULAPrefix is the machine's IPv6 unique local prefix (RFC 4193) that
+	// IPv6 project subnets come from, generated on first use
+	ULAPrefix string `json:"ula_prefix,omitempty"`
+}
+
+// LoadConfig reads the user configuration; a missing file gives the defaults
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if _, err := config.Pools(); err != nil {
+		return config, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if config.ULAPrefix != "" {
+		if _, err := config.ULA(); err != nil {
+			return config, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return config, nil
+}
+
+// SaveConfig writes the user configuration
+func SaveConfig(path string, config Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// Pools returns the subnet pools, or the defaults when none are configured
+func (c Config) Pools() ([]netip.Prefix, error) {
+	pools := c.SubnetPools
+	if len(pools) == 0 {
+		pools = DefaultSubnetPools
+	}
+
+	prefix := c.Prefix()
+	if prefix < 8 || prefix > maxProjectPrefix {
+		return nil, fmt.Errorf("subnet_prefix %d must be between 8 and %d", prefix, maxProjectPrefix)
+	}
+
+	result := make([]netip.Prefix, 0, len(pools))
+	for _, pool := range pools {
+		p, err := netip.ParsePrefix(pool)
+		if err != nil || !p.Addr().Is4() {
+			return nil, fmt.Errorf("invalid subnet pool %q (expected an IPv4 CIDR)", pool)
+		}
+		if p.Bits() > prefix {
+			return nil, fmt.Errorf("subnet pool %s is smaller than subnet_prefix /%d", pool, prefix)
+		}
+		result = append(result, p.Masked())
+	}
+	return result, nil
+}
+
+// Prefix returns the project subnet prefix length
+func (c Config) Prefix() int {
+	if c.SubnetPrefix == 0 {
+		return DefaultSubnetPrefix
+	}
+	return c.SubnetPrefix
+}
+
+// ULA returns the IPv6 unique local prefix
+func (c Config) ULA() (netip.Prefix, error) {
+	p, err := netip.ParsePrefix(c.ULAPrefix)
+	if err != nil || !p.Addr().Is6() || !ulaRange.Contains(p.Addr()) || p.Bits() > projectPrefix6 {
+		return netip.Prefix{}, fmt.Errorf("invalid ula_prefix %q (expected an fd00::/8 prefix, e.g. fd12:3456:789a::/48)", c.ULAPrefix)
+	}
+	return p.Masked(), nil
+}
+
+// GenerateULAPrefix returns a random /48 unique local prefix (RFC 4193)
+func GenerateULAPrefix() (string, error) {
+	var a [16]byte
+	a[0] = 0xfd
+	if _, err := rand.Read(a[1:6]); err != nil {
+		return "", err
+	}
+	return netip.PrefixFrom(netip.AddrFrom16(a), 48).String(), nil
+}
+
+// InPools reports whether subnet is a project subnet of the configured pools and size
+func (c Config) InPools(subnet string) bool {
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil || p.Bits() != c.Prefix() {
+		return false
+	}
+	pools, err := c.Pools()
+	if err != nil {
+		return false
+	}
+	for _, pool := range pools {
+		if pool.Contains(p.Addr()) {
+			return true
+		}
+	}
+	return false
+}
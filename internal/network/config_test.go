@@ -0,0 +1,171 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantPrefix int
+		wantErr    bool
+	}{
+		{"missing file", "", DefaultSubnetPrefix, false},
+		{"custom pool", `{"subnet_pools": ["10.210.0.0/16"], "subnet_prefix": 24}`, 24, false},
+		{"invalid json", `{`, 0, true},
+		{"invalid pool", `{"subnet_pools": ["10.210.0.0"]}`, 0, true},
+		{"ipv6 pool", `{"subnet_pools": ["fd00::/48"]}`, 0, true},
+		{"prefix too long", `{"subnet_pools": ["10.210.0.0/16"], "subnet_prefix": 28}`, 0, true},
+		{"pool smaller than prefix", `{"subnet_pools": ["10.210.0.0/25"], "subnet_prefix": 24}`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && config.Prefix() != tt.wantPrefix {
+				t.Errorf("Prefix() = %d, want %d", config.Prefix(), tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestConfig_InPools(t *testing.T) {
+	custom := Config{SubnetPools: []string{"10.210.0.0/16"}, SubnetPrefix: 24}
+
+	tests := []struct {
+		name   string
+		config Config
+		subnet string
+		want   bool
+	}{
+		{"default first", Config{}, "172.18.0.0/16", true},
+		{"default last", Config{}, "172.31.0.0/16", true},
+		{"docker bridge", Config{}, "172.17.0.0/16", false},
+		{"wrong size", Config{}, "172.18.0.0/24", false},
+		{"custom", custom, "10.210.5.0/24", true},
+		{"outside custom", custom, "172.18.0.0/16", false},
+		{"empty", Config{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.InPools(tt.subnet); got != tt.want {
+				t.Errorf("InPools(%q) = %v, want %v", tt.subnet, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectManager_AllocateSubnet_CustomPool(t *testing.T) {
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects: map[string]ProjectInfo{
+			"pinned": {Subnet: "10.210.0.0/23", Pinned: true},
+		},
+		config: Config{SubnetPools: []string{"10.210.0.0/16", "10.220.0.0/16"}, SubnetPrefix: 24},
+	}
+
+	// The pinned /23 covers the first two /24s
+	subnet, err := mgr.allocateSubnet()
+	if err != nil {
+		t.Fatalf("allocateSubnet() error = %v", err)
+	}
+	if subnet != "10.210.2.0/24" {
+		t.Errorf("Subnet = %q, want %q", subnet, "10.210.2.0/24")
+	}
+
+	// A full pool moves on to the next one
+	mgr.projects["pinned"] = ProjectInfo{Subnet: "10.210.0.0/16", Pinned: true}
+	subnet, err = mgr.allocateSubnet()
+	if err != nil {
+		t.Fatalf("allocateSubnet() error = %v", err)
+	}
+	if subnet != "10.220.0.0/24" {
+		t.Errorf("Subnet = %q, want %q", subnet, "10.220.0.0/24")
+	}
+}
+
+func TestProjectManager_GetOrCreateProject_MigratesOutOfPool(t *testing.T) {
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects: map[string]ProjectInfo{
+			"old":    {Path: "/old", Subnet: "172.18.0.0/16"},
+			"pinned": {Path: "/pinned", Subnet: "172.19.0.0/16", Pinned: true},
+		},
+		config: Config{SubnetPools: []string{"10.210.0.0/16"}, SubnetPrefix: 24},
+	}
+
+	info, changes, err := mgr.GetOrCreateProjectWithSubnet("old", "/old", nil, nil, "")
+	if err != nil {
+		t.Fatalf("GetOrCreateProjectWithSubnet() error = %v", err)
+	}
+	if info.Subnet != "10.210.0.0/24" {
+		t.Errorf("Subnet = %q, want %q", info.Subnet, "10.210.0.0/24")
+	}
+	if changes.PreviousSubnet != "172.18.0.0/16" {
+		t.Errorf("PreviousSubnet = %q, want %q", changes.PreviousSubnet, "172.18.0.0/16")
+	}
+
+	// Pinned subnets stay where they are
+	info, changes, err = mgr.GetOrCreateProjectWithSubnet("pinned", "/pinned", nil, nil, "172.19.0.0/16")
+	if err != nil {
+		t.Fatalf("GetOrCreateProjectWithSubnet() error = %v", err)
+	}
+	if info.Subnet != "172.19.0.0/16" || changes.PreviousSubnet != "" {
+		t.Errorf("pinned project moved to %q (previous %q)", info.Subnet, changes.PreviousSubnet)
+	}
+	if mgr.NeedsMigration(*info) {
+		t.Error("NeedsMigration() = true for a pinned project")
+	}
+}
+
+func TestConfig_ULA(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		wantErr bool
+	}{
+		{"fd12:3456:789a::/48", false},
+		{"fd12:3456:789a:1::/64", false},
+		{"fd12:3456:789a::1/48", false},
+		{"fc00::/48", true},
+		{"2001:db8::/48", true},
+		{"fd12:3456:789a::/80", true},
+		{"10.0.0.0/8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			_, err := Config{ULAPrefix: tt.prefix}.ULA()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ULA() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateULAPrefix(t *testing.T) {
+	prefix, err := GenerateULAPrefix()
+	if err != nil {
+		t.Fatalf("GenerateULAPrefix() error = %v", err)
+	}
+	p, err := Config{ULAPrefix: prefix}.ULA()
+	if err != nil {
+		t.Fatalf("generated prefix %q is invalid: %v", prefix, err)
+	}
+	if p.Bits() != 48 {
+		t.Errorf("prefix length = %d, want 48", p.Bits())
+	}
+}
@@ -0,0 +1,271 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Sources of a UsedSubnet
+const (
+	SourceInterface     = "interface"
+	SourceRoute         = "route"
+	SourceDockerNetwork = "docker network"
+)
+
+// minRouteBits skips default-like routes (0.0.0.0/0, a VPN's 0.0.0.0/1), which
+// would otherwise overlap every candidate subnet
+const minRouteBits = 8
+
+// UsedSubnet is an IPv4 range already in use on the host
+type UsedSubnet struct {
+	Prefix netip.Prefix
+	// Source is SourceInterface, SourceRoute or SourceDockerNetwork
+	Source string
+	// Name is the interface, route device or Docker network name
+	Name string
+	// Project is the compose project label of a Docker network
+	Project string
+}
+
+// String describes the range, e.g. "route 172.20.0.0/16 (utun3)"
+func (u UsedSubnet) String() string {
+	if u.Name == "" {
+		return fmt.Sprintf("%s %s", u.Source, u.Prefix)
+	}
+	if u.Source == SourceRoute {
+		return fmt.Sprintf("%s %s (%s)", u.Source, u.Prefix, u.Name)
+	}
+	return fmt.Sprintf("%s %s (%s)", u.Source, u.Name, u.Prefix)
+}
+
+// HostSubnets returns the IPv4 ranges of the host interfaces, the routing
+// table and Docker networks. Sources that cannot be read are skipped
+func HostSubnets() []UsedSubnet {
+	var used []UsedSubnet
+	used = append(used, interfaceSubnets()...)
+	used = append(used, routeSubnets()...)
+	used = append(used, dockerNetworkSubnets()...)
+	return used
+}
+
+// Conflicts returns the used ranges that overlap subnet
+// Docker networks of projectName, and the interfaces and routes Docker creates
+// for them, belong to the project and are not conflicts
+func Conflicts(subnet string, used []UsedSubnet, projectName string) []UsedSubnet {
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return nil
+	}
+	p = p.Masked()
+
+	own := make(map[netip.Prefix]bool)
+	if projectName != "" {
+		for _, u := range used {
+			if u.Source == SourceDockerNetwork && u.Project == projectName {
+				own[u.Prefix] = true
+			}
+		}
+	}
+
+	var conflicts []UsedSubnet
+	for _, u := range used {
+		if own[u.Prefix] || !p.Overlaps(u.Prefix) {
+			continue
+		}
+		conflicts = append(conflicts, u)
+	}
+	return conflicts
+}
+
+func interfaceSubnets() []UsedSubnet {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	var used []UsedSubnet
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			p, err := netip.ParsePrefix(ipNet.String())
+			if err != nil {
+				continue
+			}
+			used = append(used, UsedSubnet{Prefix: p.Masked(), Source: SourceInterface, Name: iface.Name})
+		}
+	}
+	return used
+}
+
+func routeSubnets() []UsedSubnet {
+	switch runtime.GOOS {
+	case "linux":
+		output, err := exec.Command("ip", "-4", "route", "show").Output()
+		if err != nil {
+			return nil
+		}
+		return parseIPRoutes(string(output))
+	case "darwin":
+		output, err := exec.Command("netstat", "-rn", "-f", "inet").Output()
+		if err != nil {
+			return nil
+		}
+		return parseNetstatRoutes(string(output))
+	}
+	return nil
+}
+
+// parseIPRoutes parses `ip -4 route show` output:
+//
+//	default via 192.168.1.1 dev eth0
+//	172.20.0.0/16 dev tun0 scope link
+//	10.8.0.1 dev tun0 scope link
+func parseIPRoutes(output string) []UsedSubnet {
+	var used []UsedSubnet
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		dest := fields[0]
+		// Route types (unreachable, blackhole, ...) come before the destination
+		if len(fields) > 1 && !strings.ContainsAny(dest[:1], "0123456789") {
+			dest = fields[1]
+		}
+		if !strings.Contains(dest, "/") {
+			dest += "/32"
+		}
+		p, err := netip.ParsePrefix(dest)
+		if err != nil || !usableRoute(p) {
+			continue
+		}
+
+		var device string
+		for i := 0; i < len(fields)-1; i++ {
+			if fields[i] == "dev" {
+				device = fields[i+1]
+			}
+		}
+		used = append(used, UsedSubnet{Prefix: p.Masked(), Source: SourceRoute, Name: device})
+	}
+	return used
+}
+
+// parseNetstatRoutes parses `netstat -rn -f inet` output on macOS, where
+// destinations drop trailing zero octets:
+//
+//	Destination        Gateway            Flags        Netif Expire
+//	default            192.168.1.1        UGScg          en0
+//	172.20/16          utun3              USc          utun3
+//	192.168.1          link#11            UCS            en0
+func parseNetstatRoutes(output string) []UsedSubnet {
+	var used []UsedSubnet
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		p, ok := parseNetstatDestination(fields[0])
+		if !ok || !usableRoute(p) {
+			continue
+		}
+		used = append(used, UsedSubnet{Prefix: p, Source: SourceRoute, Name: fields[3]})
+	}
+	return used
+}
+
+// parseNetstatDestination expands "172.20/16" to 172.20.0.0/16, and "192.168.1"
+// to 192.168.1.0/24 (the prefix covers the octets given)
+func parseNetstatDestination(dest string) (netip.Prefix, bool) {
+	addr, bitsStr, hasBits := strings.Cut(dest, "/")
+	octets := strings.Split(addr, ".")
+	if len(octets) > 4 {
+		return netip.Prefix{}, false
+	}
+	for _, octet := range octets {
+		if _, err := strconv.Atoi(octet); err != nil {
+			return netip.Prefix{}, false
+		}
+	}
+
+	bits := len(octets) * 8
+	if hasBits {
+		var err error
+		if bits, err = strconv.Atoi(bitsStr); err != nil {
+			return netip.Prefix{}, false
+		}
+	}
+	for len(octets) < 4 {
+		octets = append(octets, "0")
+	}
+
+	p, err := netip.ParsePrefix(strings.Join(octets, ".") + "/" + strconv.Itoa(bits))
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return p.Masked(), true
+}
+
+// usableRoute skips default-like, loopback, link-local and multicast routes
+func usableRoute(p netip.Prefix) bool {
+	addr := p.Addr()
+	return addr.Is4() && p.Bits() >= minRouteBits &&
+		!addr.IsLoopback() && !addr.IsLinkLocalUnicast() && !addr.IsMulticast()
+}
+
+func dockerNetworkSubnets() []UsedSubnet {
+	ids, err := exec.Command("docker", "network", "ls", "-q").Output()
+	if err != nil {
+		return nil
+	}
+	args := append([]string{"network", "inspect", "--format",
+		`{{.Name}}|{{index .Labels "com.docker.compose.project"}}|{{range .IPAM.Config}}{{.Subnet}} {{end}}`},
+		strings.Fields(string(ids))...)
+	if len(args) == 4 {
+		return nil
+	}
+	output, err := exec.Command("docker", args...).Output()
+	if err != nil {
+		return nil
+	}
+	return parseDockerNetworks(string(output))
+}
+
+// parseDockerNetworks parses "name|project|subnet subnet" lines
+func parseDockerNetworks(output string) []UsedSubnet {
+	var used []UsedSubnet
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "|", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, subnet := range strings.Fields(parts[2]) {
+			p, err := netip.ParsePrefix(subnet)
+			if err != nil || !p.Addr().Is4() {
+				continue
+			}
+			used = append(used, UsedSubnet{
+				Prefix:  p.Masked(),
+				Source:  SourceDockerNetwork,
+				Name:    parts[0],
+				Project: parts[1],
+			})
+		}
+	}
+	return used
+}
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func prefixes(used []UsedSubnet) []string {
+	var result []string
+	for _, u := range used {
+		result = append(result, u.Prefix.String()+" "+u.Name)
+	}
+	return result
+}
+
+func TestParseIPRoutes(t *testing.T) {
+	output := `default via 192.168.1.1 dev eth0 proto dhcp metric 100
+0.0.0.0/1 via 10.8.0.1 dev tun0
+10.8.0.1 dev tun0 scope link
+172.20.0.0/16 dev tun0 scope link
+172.18.0.0/24 dev br-1a2b3c proto kernel scope link src 172.18.0.1
+unreachable 10.99.0.0/16
+169.254.0.0/16 dev eth0 scope link metric 1000
+`
+	want := []string{
+		"10.8.0.1/32 tun0",
+		"172.20.0.0/16 tun0",
+		"172.18.0.0/24 br-1a2b3c",
+		"10.99.0.0/16 ",
+	}
+	if got := prefixes(parseIPRoutes(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPRoutes() = %v, want %v", got, want)
+	}
+}
+
+func TestParseNetstatRoutes(t *testing.T) {
+	output := `Routing tables
+
+Internet:
+Destination        Gateway            Flags               Netif Expire
+default            192.168.1.1        UGScg                 en0
+127                127.0.0.1          UCS                   lo0
+172.20/16          utun3              USc                 utun3
+192.168.1          link#11            UCS                   en0      !
+192.168.1.1/32     link#11            UCS                   en0      !
+224.0.0/4          link#11            UmCS                  en0      !
+`
+	want := []string{
+		"172.20.0.0/16 utun3",
+		"192.168.1.0/24 en0",
+		"192.168.1.1/32 en0",
+	}
+	if got := prefixes(parseNetstatRoutes(output)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNetstatRoutes() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDockerNetworks(t *testing.T) {
+	output := `bridge||172.17.0.0/16
+myapp_default|myapp|172.18.0.0/24 fd00::/64
+host||
+`
+	used := parseDockerNetworks(output)
+	want := []string{"172.17.0.0/16 bridge", "172.18.0.0/24 myapp_default"}
+	if got := prefixes(used); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseDockerNetworks() = %v, want %v", got, want)
+	}
+	if used[1].Project != "myapp" {
+		t.Errorf("Project = %q, want %q", used[1].Project, "myapp")
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	used := []UsedSubnet{
+		{Prefix: netip.MustParsePrefix("172.20.0.0/16"), Source: SourceRoute, Name: "utun3"},
+		{Prefix: netip.MustParsePrefix("172.18.0.0/24"), Source: SourceDockerNetwork, Name: "myapp_default", Project: "myapp"},
+		{Prefix: netip.MustParsePrefix("172.18.0.0/24"), Source: SourceInterface, Name: "br-1a2b3c"},
+		{Prefix: netip.MustParsePrefix("172.18.1.0/24"), Source: SourceDockerNetwork, Name: "other_default", Project: "other"},
+	}
+
+	tests := []struct {
+		name    string
+		subnet  string
+		project string
+		want    []string
+	}{
+		{"vpn route", "172.20.0.0/16", "myapp", []string{"172.20.0.0/16 utun3"}},
+		{"own networks skipped", "172.18.0.0/16", "myapp", []string{"172.18.1.0/24 other_default"}},
+		{"new project", "172.18.0.0/16", "", []string{"172.18.0.0/24 myapp_default", "172.18.0.0/24 br-1a2b3c", "172.18.1.0/24 other_default"}},
+		{"free", "172.21.0.0/16", "myapp", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prefixes(Conflicts(tt.subnet, used, tt.project)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Conflicts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsedSubnet_String(t *testing.T) {
+	tests := []struct {
+		used UsedSubnet
+		want string
+	}{
+		{UsedSubnet{Prefix: netip.MustParsePrefix("172.20.0.0/16"), Source: SourceRoute, Name: "utun3"}, "route 172.20.0.0/16 (utun3)"},
+		{UsedSubnet{Prefix: netip.MustParsePrefix("172.18.0.0/24"), Source: SourceDockerNetwork, Name: "web_default"}, "docker network web_default (172.18.0.0/24)"},
+		{UsedSubnet{Prefix: netip.MustParsePrefix("10.99.0.0/16"), Source: SourceRoute}, "route 10.99.0.0/16"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.used.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestProjectManager_AllocateSubnet_SkipsHostSubnets(t *testing.T) {
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects: map[string]ProjectInfo{
+			"existing": {Subnet: "172.18.0.0/16"},
+		},
+	}
+	mgr.SetHostSubnets([]UsedSubnet{
+		{Prefix: netip.MustParsePrefix("172.19.5.0/24"), Source: SourceDockerNetwork, Name: "outside_default"},
+		{Prefix: netip.MustParsePrefix("172.20.0.0/15"), Source: SourceRoute, Name: "utun3"},
+	})
+
+	subnet, err := mgr.allocateSubnet()
+	if err != nil {
+		t.Fatalf("allocateSubnet() error = %v", err)
+	}
+	if subnet != "172.22.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", subnet, "172.22.0.0/16")
+	}
+}
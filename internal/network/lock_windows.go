@@ -0,0 +1,39 @@
+//go:build windows
+
+package network
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockTimeout = 10 * time.Second
+	// lockStale is the age after which a lock left by a dead process is removed
+	lockStale = 30 * time.Second
+)
+
+// lockFile takes an exclusive lock by creating path, and waits while another
+// process holds it. The lock is released by the returned function
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
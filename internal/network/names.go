@@ -0,0 +1,122 @@
+package network
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ResolveProject returns the registry name for a project in projectPath
+// A name registered for another directory that still exists is a collision:
+// with autoSuffix the project gets the first free name-2, name-3, ... (reusing
+// the one it got before), otherwise ResolveProject returns an error
+// The returned bool reports whether the name was suffixed
+func (m *ProjectManager) ResolveProject(projectName, projectPath string, autoSuffix bool) (string, bool, error) {
+	info, ok := m.projects[projectName]
+	if !ok || !collides(info.Path, projectPath) {
+		return projectName, false, nil
+	}
+
+	// A suffixed name this directory got before
+	for _, name := range sortedProjectNames(m.projects) {
+		other := m.projects[name]
+		if other.BaseName == projectName && samePath(other.Path, projectPath) {
+			return name, true, nil
+		}
+	}
+
+	if !autoSuffix {
+		return "", false, fmt.Errorf("project '%s' is already registered for %s; use --project-name to choose another name for %s", projectName, info.Path, projectPath)
+	}
+
+	for i := 2; ; i++ {
+		name := fmt.Sprintf("%s-%d", projectName, i)
+		if _, taken := m.projects[name]; !taken {
+			return name, true, nil
+		}
+	}
+}
+
+// SetNameOrigin records where a project's name came from: the name it was
+// suffixed from (see ResolveProject) and its git instance, each "" when unused
+func (m *ProjectManager) SetNameOrigin(projectName, baseName, instance string) error {
+	return m.update(func() (bool, error) {
+		info, ok := m.projects[projectName]
+		if !ok {
+			return false, fmt.Errorf("project '%s' is not registered", projectName)
+		}
+		if info.BaseName == baseName && info.Instance == instance {
+			return false, nil
+		}
+		info.BaseName = baseName
+		info.Instance = instance
+		m.projects[projectName] = info
+		return true, nil
+	})
+}
+
+// Collisions returns the other registered projects a project collides with:
+// those derived from the same name, and those registered for the same directory
+// (except git branch instances, which share their checkout on purpose)
+func (m *ProjectManager) Collisions(projectName string) []string {
+	info, ok := m.projects[projectName]
+	if !ok {
+		return nil
+	}
+	base := baseName(projectName, info)
+
+	var result []string
+	for _, name := range sortedProjectNames(m.projects) {
+		if name == projectName {
+			continue
+		}
+		other := m.projects[name]
+		sharesPath := samePath(other.Path, info.Path) && info.Instance == "" && other.Instance == ""
+		if baseName(name, other) == base || sharesPath {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// baseName returns the name a project was derived from, or its own name
+func baseName(name string, info ProjectInfo) string {
+	if info.BaseName != "" {
+		return info.BaseName
+	}
+	return name
+}
+
+// collides reports whether a project registered for registered conflicts with
+// one in path; a registered directory that is gone counts as moved
+func collides(registered, path string) bool {
+	if registered == "" || samePath(registered, path) {
+		return false
+	}
+	_, err := os.Stat(registered)
+	return !os.IsNotExist(err)
+}
+
+// samePath compares two project directories
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// sortedProjectNames returns the project names in order
+func sortedProjectNames(projects map[string]ProjectInfo) []string {
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
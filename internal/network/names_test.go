@@ -0,0 +1,157 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProjectManager_ResolveProject(t *testing.T) {
+	root := t.TempDir()
+	first := root
+	second := filepath.Join(root, "other", "api")
+	gone := filepath.Join(root, "gone")
+
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects: map[string]ProjectInfo{
+			"api":    {Path: first, Subnet: "172.18.0.0/16"},
+			"web":    {Path: first, Subnet: "172.19.0.0/16"},
+			"web-2":  {Path: filepath.Join(root, "web2"), Subnet: "172.20.0.0/16", BaseName: "web"},
+			"moved":  {Path: gone, Subnet: "172.21.0.0/16"},
+			"worker": {Path: first, Subnet: "172.22.0.0/16"},
+			"worker-2": {
+				Path: second, Subnet: "172.23.0.0/16", BaseName: "worker",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		project    string
+		path       string
+		autoSuffix bool
+		expected   string
+		wantSuffix bool
+		wantErr    bool
+	}{
+		{name: "unregistered", project: "new", path: second, expected: "new"},
+		{name: "same path", project: "api", path: first + "/", expected: "api"},
+		{name: "registered path gone", project: "moved", path: second, expected: "moved"},
+		{name: "collision refused", project: "api", path: second, wantErr: true},
+		{name: "collision suffixed", project: "api", path: second, autoSuffix: true, expected: "api-2", wantSuffix: true},
+		{name: "suffix skips taken names", project: "web", path: second, autoSuffix: true, expected: "web-3", wantSuffix: true},
+		{name: "suffix reused", project: "worker", path: second, expected: "worker-2", wantSuffix: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, suffixed, err := mgr.ResolveProject(tt.project, tt.path, tt.autoSuffix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.expected || suffixed != tt.wantSuffix {
+				t.Errorf("ResolveProject() = %q, %v, want %q, %v", name, suffixed, tt.expected, tt.wantSuffix)
+			}
+		})
+	}
+}
+
+func TestProjectManager_Collisions(t *testing.T) {
+	mgr := &ProjectManager{
+		projects: map[string]ProjectInfo{
+			"api":   {Path: "/src/api"},
+			"api-2": {Path: "/work/api", BaseName: "api"},
+			"shop":  {Path: "/src/api"},
+			"web":   {Path: "/src/web"},
+			// A branch instance in the same checkout as web
+			"web-feature-x": {Path: "/src/web", Instance: "feature-x"},
+		},
+	}
+
+	tests := []struct {
+		project  string
+		expected []string
+	}{
+		{"api", []string{"api-2", "shop"}},
+		{"api-2", []string{"api"}},
+		{"shop", []string{"api"}},
+		{"web", nil},
+		{"web-feature-x", nil},
+		{"missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			if got := mgr.Collisions(tt.project); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Collisions(%q) = %v, want %v", tt.project, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProjectManager_SetNameOrigin(t *testing.T) {
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects:   map[string]ProjectInfo{"api-2": {Path: "/work/api", Subnet: "172.18.0.0/16"}},
+	}
+
+	if err := mgr.SetNameOrigin("api-2", "api", "feature-x"); err != nil {
+		t.Fatalf("SetNameOrigin() error = %v", err)
+	}
+	if err := mgr.loadGlobal(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mgr.projects["api-2"]; got.BaseName != "api" || got.Instance != "feature-x" {
+		t.Errorf("BaseName, Instance = %q, %q, want %q, %q", got.BaseName, got.Instance, "api", "feature-x")
+	}
+	if err := mgr.SetNameOrigin("missing", "api", ""); err == nil {
+		t.Error("SetNameOrigin() on an unregistered project should fail")
+	}
+}
+
+func TestProjectManager_GetOrCreateProject_NameCollision(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "projects.json")
+
+	// Both runs resolved "api" before either registered it
+	mgrA := &ProjectManager{globalPath: globalPath, projects: make(map[string]ProjectInfo)}
+	mgrB := &ProjectManager{globalPath: globalPath, projects: make(map[string]ProjectInfo)}
+	if _, _, err := mgrA.GetOrCreateProject("api", first, nil, nil); err != nil {
+		t.Fatalf("GetOrCreateProject() error = %v", err)
+	}
+
+	// An explicit name is refused
+	if _, _, err := mgrB.GetOrCreateProject("api", second, nil, nil); err == nil {
+		t.Fatal("GetOrCreateProject() took over a project registered for another directory")
+	}
+
+	// A name from the directory is suffixed
+	mgrB.AllowNameSuffix(true)
+	info, changes, err := mgrB.GetOrCreateProject("api", second, nil, nil)
+	if err != nil {
+		t.Fatalf("GetOrCreateProject() error = %v", err)
+	}
+	if changes.Name != "api-2" || changes.SuffixedFrom != "api" || info.BaseName != "api" {
+		t.Errorf("registered as %q (suffixed from %q, base %q), want api-2 from api", changes.Name, changes.SuffixedFrom, info.BaseName)
+	}
+
+	original, _ := mgrB.GetProject("api")
+	if original.Path != first || original.Subnet == info.Subnet {
+		t.Errorf("api changed to %s (%s), want %s with its own subnet", original.Path, original.Subnet, first)
+	}
+
+	// A gone directory counts as moved
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	mgrB.AllowNameSuffix(false)
+	_, changes, err = mgrB.GetOrCreateProject("api", second, nil, nil)
+	if err != nil {
+		t.Fatalf("GetOrCreateProject() after move error = %v", err)
+	}
+	if changes.Name != "api" {
+		t.Errorf("registered as %q after move, want api", changes.Name)
+	}
+}
@@ -0,0 +1,160 @@
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// maxSubRangeBits is the smallest sub-range handed to a network (/28)
+const maxSubRangeBits = 28
+
+// NetworkRequest describes a project network that needs a subnet
+type NetworkRequest struct {
+	Name string
+	// Subnet is a user-specified subnet, kept when inside the project subnet
+	Subnet string
+	// StaticIPs maps service name to a user-specified address on this network,
+	// kept when inside the project subnet
+	StaticIPs map[string]string
+}
+
+// AllocateNetworkSubnets splits the project subnet into sub-ranges (a /16 into
+// /24s) and assigns one to each network, in request order
+// User subnets are kept, and networks with static IPs get the sub-range that
+// contains them; both must lie inside the project subnet
+// Returns map[network name]subnet
+func AllocateNetworkSubnets(projectSubnet string, requests []NetworkRequest) (map[string]string, error) {
+	project, err := netip.ParsePrefix(projectSubnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project subnet %q: %w", projectSubnet, err)
+	}
+	project = project.Masked()
+
+	bits := project.Bits() + 8
+	if bits > maxSubRangeBits {
+		bits = maxSubRangeBits
+	}
+	if bits <= project.Bits() {
+		return nil, fmt.Errorf("project subnet %s is too small to split into networks", projectSubnet)
+	}
+
+	result := make(map[string]string)
+	var taken []netip.Prefix
+
+	overlaps := func(p netip.Prefix) bool {
+		for _, t := range taken {
+			if t.Overlaps(p) {
+				return true
+			}
+		}
+		return false
+	}
+	reserve := func(name string, p netip.Prefix) error {
+		if overlaps(p) {
+			return fmt.Errorf("network '%s': subnet %s overlaps another network of the project", name, p)
+		}
+		taken = append(taken, p)
+		result[name] = p.String()
+		return nil
+	}
+
+	// 1. User-specified subnets
+	for _, req := range requests {
+		if req.Subnet == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(req.Subnet)
+		if err != nil {
+			return nil, fmt.Errorf("network '%s': invalid subnet %q", req.Name, req.Subnet)
+		}
+		p = p.Masked()
+		if !project.Contains(p.Addr()) || p.Bits() < project.Bits() {
+			return nil, fmt.Errorf("network '%s': subnet %s is outside the project subnet %s", req.Name, p, project)
+		}
+		for service, ip := range req.StaticIPs {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil || !p.Contains(addr) {
+				return nil, fmt.Errorf("service '%s': ipv4_address %s is outside network '%s' (%s)", service, ip, req.Name, p)
+			}
+		}
+		if err := reserve(req.Name, p); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. Networks with static IPs get the sub-range containing them
+	for _, req := range requests {
+		if req.Subnet != "" || len(req.StaticIPs) == 0 {
+			continue
+		}
+		var subRange netip.Prefix
+		for _, service := range sortedServiceNames(req.StaticIPs) {
+			ip := req.StaticIPs[service]
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				return nil, fmt.Errorf("service '%s': invalid ipv4_address %q", service, ip)
+			}
+			if !project.Contains(addr) {
+				return nil, fmt.Errorf("service '%s': ipv4_address %s is outside the project subnet %s", service, ip, project)
+			}
+			p, _ := addr.Prefix(bits)
+			if !subRange.IsValid() {
+				subRange = p
+			} else if p != subRange {
+				return nil, fmt.Errorf("network '%s': static IPs %s span more than one /%d sub-range; set an ipam subnet", req.Name, ip, bits)
+			}
+		}
+		if err := reserve(req.Name, subRange); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Remaining networks get the lowest free sub-range
+	next := netip.PrefixFrom(project.Addr(), bits)
+	for _, req := range requests {
+		if _, ok := result[req.Name]; ok {
+			continue
+		}
+		for overlaps(next) {
+			var ok bool
+			if next, ok = nextPrefix(next); !ok || !project.Contains(next.Addr()) {
+				return nil, fmt.Errorf("project subnet %s has no free /%d range for network '%s'", project, bits, req.Name)
+			}
+		}
+		if err := reserve(req.Name, next); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// nextPrefix returns the prefix of the same size right after p
+func nextPrefix(p netip.Prefix) (netip.Prefix, bool) {
+	if p.Bits() <= 0 {
+		return netip.Prefix{}, false
+	}
+	// Add one at the last network bit, carrying into the bytes before it
+	a := p.Masked().Addr().AsSlice()
+	bit := p.Bits() - 1
+	carry := uint(1) << (7 - bit%8)
+	for i := bit / 8; i >= 0 && carry > 0; i-- {
+		sum := uint(a[i]) + carry
+		a[i], carry = byte(sum), sum>>8
+	}
+	if carry > 0 {
+		return netip.Prefix{}, false
+	}
+	addr, _ := netip.AddrFromSlice(a)
+	return netip.PrefixFrom(addr, p.Bits()), true
+}
+
+func sortedServiceNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
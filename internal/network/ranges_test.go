@@ -0,0 +1,98 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocateNetworkSubnets(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  string
+		requests []NetworkRequest
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "in order",
+			project:  "172.25.0.0/16",
+			requests: []NetworkRequest{{Name: "default"}, {Name: "backend"}},
+			want:     map[string]string{"default": "172.25.0.0/24", "backend": "172.25.1.0/24"},
+		},
+		{
+			name:    "user subnet kept",
+			project: "172.25.0.0/16",
+			requests: []NetworkRequest{
+				{Name: "default"},
+				{Name: "backend", Subnet: "172.25.0.0/24"},
+			},
+			want: map[string]string{"default": "172.25.1.0/24", "backend": "172.25.0.0/24"},
+		},
+		{
+			name:    "static IPs pick the sub-range",
+			project: "172.25.0.0/16",
+			requests: []NetworkRequest{
+				{Name: "default"},
+				{Name: "backend", StaticIPs: map[string]string{"db": "172.25.7.10", "cache": "172.25.7.11"}},
+			},
+			want: map[string]string{"default": "172.25.0.0/24", "backend": "172.25.7.0/24"},
+		},
+		{
+			name:     "small project subnet",
+			project:  "172.25.0.0/24",
+			requests: []NetworkRequest{{Name: "default"}, {Name: "backend"}},
+			want:     map[string]string{"default": "172.25.0.0/28", "backend": "172.25.0.16/28"},
+		},
+		{
+			name:     "subnet outside project",
+			project:  "172.25.0.0/16",
+			requests: []NetworkRequest{{Name: "backend", Subnet: "10.0.0.0/24"}},
+			wantErr:  true,
+		},
+		{
+			name:     "static IP outside project",
+			project:  "172.25.0.0/16",
+			requests: []NetworkRequest{{Name: "backend", StaticIPs: map[string]string{"db": "10.0.0.5"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "static IP outside user subnet",
+			project:  "172.25.0.0/16",
+			requests: []NetworkRequest{{Name: "backend", Subnet: "172.25.1.0/24", StaticIPs: map[string]string{"db": "172.25.2.5"}}},
+			wantErr:  true,
+		},
+		{
+			name:     "static IPs span sub-ranges",
+			project:  "172.25.0.0/16",
+			requests: []NetworkRequest{{Name: "backend", StaticIPs: map[string]string{"db": "172.25.1.5", "cache": "172.25.2.5"}}},
+			wantErr:  true,
+		},
+		{
+			name:    "overlapping subnets",
+			project: "172.25.0.0/16",
+			requests: []NetworkRequest{
+				{Name: "frontend", Subnet: "172.25.0.0/23"},
+				{Name: "backend", Subnet: "172.25.1.0/24"},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "invalid project subnet",
+			project:  "172.25.0.0",
+			requests: []NetworkRequest{{Name: "default"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AllocateNetworkSubnets(tt.project, tt.requests)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AllocateNetworkSubnets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllocateNetworkSubnets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RegistryVersion is the current schema version of projects.json
+//
+//	1: a bare map of project name to project info
+//	2: the {version, projects, settings} envelope
+//	3: the deprecated "domain" field folded into "domains"
+const RegistryVersion = 3
+
+// Registry is the on-disk layout of projects.json
+type Registry struct {
+	Version  int                    `json:"version"`
+	Projects map[string]ProjectInfo `json:"projects"`
+	// Settings holds registry-wide values; unknown keys are kept as they are
+	Settings map[string]any `json:"settings,omitempty"`
+}
+
+// migration upgrades a decoded registry from version from to from+1
+// Migrations work on the raw JSON so they keep working as ProjectInfo changes
+type migration struct {
+	from        int
+	description string
+	apply       func(doc map[string]any) (map[string]any, error)
+}
+
+// migrations are applied in order; add new ones at the end and bump RegistryVersion
+var migrations = []migration{
+	{
+		from:        1,
+		description: "wrap projects in a versioned envelope",
+		apply: func(doc map[string]any) (map[string]any, error) {
+			return map[string]any{"projects": doc, "settings": map[string]any{}}, nil
+		},
+	},
+	{
+		from:        2,
+		description: "move the deprecated domain field into domains",
+		apply: func(doc map[string]any) (map[string]any, error) {
+			projects, _ := doc["projects"].(map[string]any)
+			for name, value := range projects {
+				project, ok := value.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("project '%s' is not an object", name)
+				}
+				domain, _ := project["domain"].(string)
+				delete(project, "domain")
+				if domains, _ := project["domains"].([]any); len(domains) == 0 && domain != "" {
+					project["domains"] = []any{domain}
+				}
+			}
+			return doc, nil
+		},
+	},
+}
+
+// MigrationResult describes a registry migration
+type MigrationResult struct {
+	From int
+	To   int
+	// Applied lists the descriptions of the migrations that ran, in order
+	Applied []string
+}
+
+// MigrateRegistry upgrades projects.json content to RegistryVersion
+// Empty data is an empty registry; data from a newer bootapp is an error
+func MigrateRegistry(data []byte) (*Registry, MigrationResult, error) {
+	result := MigrationResult{From: RegistryVersion, To: RegistryVersion}
+	if len(data) == 0 {
+		return &Registry{Version: RegistryVersion, Projects: make(map[string]ProjectInfo)}, result, nil
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, result, err
+	}
+	if doc == nil {
+		doc = make(map[string]any)
+	}
+
+	version := registryVersion(doc)
+	result.From = version
+	if version > RegistryVersion {
+		return nil, result, fmt.Errorf("registry version %d is newer than this bootapp supports (%d); upgrade bootapp", version, RegistryVersion)
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		var err error
+		if doc, err = m.apply(doc); err != nil {
+			return nil, result, fmt.Errorf("migration %d -> %d: %w", m.from, m.from+1, err)
+		}
+		doc["version"] = m.from + 1
+		version = m.from + 1
+		result.Applied = append(result.Applied, m.description)
+	}
+
+	// Re-encode and decode into the typed registry
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, result, err
+	}
+	registry := &Registry{}
+	if err := json.Unmarshal(migrated, registry); err != nil {
+		return nil, result, err
+	}
+	if registry.Projects == nil {
+		registry.Projects = make(map[string]ProjectInfo)
+	}
+	return registry, result, nil
+}
+
+// registryVersion returns the schema version of a decoded registry
+// Version 1 files have no version: every top-level value is a project
+func registryVersion(doc map[string]any) int {
+	if version, ok := doc["version"].(float64); ok {
+		if _, ok := doc["projects"]; ok {
+			return int(version)
+		}
+	}
+	return 1
+}
+
+// MigrateRegistryFile migrates the registry at path in place, keeping a copy
+// of the original in <path>.v<version>.bak. With dryRun nothing is written
+func MigrateRegistryFile(path string, dryRun bool) (MigrationResult, error) {
+	var result MigrationResult
+	err := withFileLock(path, func() error {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		registry, res, err := MigrateRegistry(data)
+		result = res
+		if err != nil || dryRun || len(res.Applied) == 0 {
+			return err
+		}
+		return writeRegistry(path, data, res.From, registry)
+	})
+	return result, err
+}
+
+// writeRegistry saves a registry, first backing up original when it was
+// migrated from an older version
+func writeRegistry(path string, original []byte, from int, registry *Registry) error {
+	if len(original) > 0 && from < RegistryVersion {
+		backup := fmt.Sprintf("%s.v%d.bak", path, from)
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			if err := writeFileAtomic(backup, original, 0644); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		}
+	}
+
+	registry.Version = RegistryVersion
+	data, err := json.MarshalIndent(registry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,179 @@
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const legacyRegistry = `{
+  "shop": {"path": "/shop", "subnet": "172.18.0.0/16", "domain": "shop.test"},
+  "blog": {"path": "/blog", "subnet": "172.19.0.0/16", "domains": ["blog.test"], "domain": "old.test"}
+}`
+
+func TestMigrateRegistry(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantFrom    int
+		wantApplied int
+		wantDomains map[string][]string
+		wantErr     bool
+	}{
+		{
+			name:        "version 1",
+			data:        legacyRegistry,
+			wantFrom:    1,
+			wantApplied: 2,
+			wantDomains: map[string][]string{"shop": {"shop.test"}, "blog": {"blog.test"}},
+		},
+		{
+			name:        "version 2",
+			data:        `{"version": 2, "projects": {"shop": {"subnet": "172.18.0.0/16", "domain": "shop.test"}}, "settings": {}}`,
+			wantFrom:    2,
+			wantApplied: 1,
+			wantDomains: map[string][]string{"shop": {"shop.test"}},
+		},
+		{
+			name:        "current",
+			data:        `{"version": 3, "projects": {"shop": {"subnet": "172.18.0.0/16", "domains": ["shop.test"]}}}`,
+			wantFrom:    3,
+			wantDomains: map[string][]string{"shop": {"shop.test"}},
+		},
+		{
+			name:        "project named version",
+			data:        `{"version": {"path": "/version", "subnet": "172.18.0.0/16"}}`,
+			wantFrom:    1,
+			wantApplied: 2,
+			wantDomains: map[string][]string{"version": nil},
+		},
+		{
+			name:        "empty",
+			data:        "",
+			wantFrom:    RegistryVersion,
+			wantDomains: map[string][]string{},
+		},
+		{
+			name:    "newer version",
+			data:    `{"version": 99, "projects": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, result, err := MigrateRegistry([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MigrateRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.From != tt.wantFrom || result.To != RegistryVersion {
+				t.Errorf("result = %d -> %d, want %d -> %d", result.From, result.To, tt.wantFrom, RegistryVersion)
+			}
+			if len(result.Applied) != tt.wantApplied {
+				t.Errorf("applied %v, want %d migrations", result.Applied, tt.wantApplied)
+			}
+			domains := make(map[string][]string)
+			for name, info := range registry.Projects {
+				domains[name] = info.Domains
+			}
+			if !reflect.DeepEqual(domains, tt.wantDomains) {
+				t.Errorf("domains = %v, want %v", domains, tt.wantDomains)
+			}
+		})
+	}
+}
+
+func TestMigrateRegistryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if err := os.WriteFile(path, []byte(legacyRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry run leaves the file alone
+	if _, err := MigrateRegistryFile(path, true); err != nil {
+		t.Fatalf("MigrateRegistryFile(dry run) error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyRegistry {
+		t.Error("dry run modified the registry")
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("dry run created a backup")
+	}
+
+	result, err := MigrateRegistryFile(path, false)
+	if err != nil {
+		t.Fatalf("MigrateRegistryFile() error = %v", err)
+	}
+	if result.From != 1 {
+		t.Errorf("From = %d, want 1", result.From)
+	}
+	if backup, _ := os.ReadFile(path + ".v1.bak"); string(backup) != legacyRegistry {
+		t.Error("backup does not hold the original registry")
+	}
+
+	var registry Registry
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &registry); err != nil {
+		t.Fatalf("migrated registry is not valid: %v", err)
+	}
+	if registry.Version != RegistryVersion || len(registry.Projects) != 2 {
+		t.Errorf("migrated registry = version %d with %d projects", registry.Version, len(registry.Projects))
+	}
+
+	// Running again is a no-op
+	if result, err := MigrateRegistryFile(path, false); err != nil || len(result.Applied) != 0 {
+		t.Errorf("second MigrateRegistryFile() = %v, %v", result.Applied, err)
+	}
+}
+
+func TestProjectManager_MigratesOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if err := os.WriteFile(path, []byte(legacyRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loading migrates in memory only, and a no-op update writes nothing
+	mgr := &ProjectManager{globalPath: path, projects: make(map[string]ProjectInfo)}
+	if err := mgr.loadGlobal(); err != nil {
+		t.Fatalf("loadGlobal() error = %v", err)
+	}
+	if err := mgr.update(func() (bool, error) { return false, nil }); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+	if got := mgr.projects["shop"].Domains; !reflect.DeepEqual(got, []string{"shop.test"}) {
+		t.Errorf("shop domains = %v", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyRegistry {
+		t.Error("reading the registry rewrote it")
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("reading the registry created a backup")
+	}
+
+	// The first change saves the migrated registry and backs up the original
+	if err := mgr.SetNameOrigin("shop", "", "feature-x"); err != nil {
+		t.Fatalf("SetNameOrigin() error = %v", err)
+	}
+	if backup, _ := os.ReadFile(path + ".v1.bak"); string(backup) != legacyRegistry {
+		t.Error("backup does not hold the original registry")
+	}
+
+	var registry Registry
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &registry); err != nil || registry.Version != RegistryVersion {
+		t.Errorf("registry not saved at version %d: %v", RegistryVersion, err)
+	}
+	if registry.Projects["shop"].Instance != "feature-x" || len(registry.Projects["blog"].Domains) != 1 {
+		t.Errorf("saved projects = %+v", registry.Projects)
+	}
+}
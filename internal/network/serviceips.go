@@ -0,0 +1,80 @@
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// AssignServiceIPs gives each service a stable address on a network subnet
+// User static IPs are kept, addresses from previous runs are reused while they
+// stay inside the subnet (also for services no longer active, so they get the
+// same address back), and new services take the lowest free address in name order
+// Returns map[service name]IP
+func AssignServiceIPs(subnet string, services []string, static, previous map[string]string) (map[string]string, error) {
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network subnet %q: %w", subnet, err)
+	}
+	p = p.Masked()
+
+	result := make(map[string]string)
+	taken := make(map[netip.Addr]bool)
+	reserve := func(service, ip string) bool {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !p.Contains(addr) || taken[addr] {
+			return false
+		}
+		taken[addr] = true
+		result[service] = addr.String()
+		return true
+	}
+
+	// 1. User static IPs, then 2. addresses from previous runs
+	for _, service := range sortedServiceNames(static) {
+		reserve(service, static[service])
+	}
+	for _, service := range sortedServiceNames(previous) {
+		if _, ok := result[service]; !ok {
+			reserve(service, previous[service])
+		}
+	}
+
+	// 3. New services, from the first address after the gateway
+	sorted := append([]string(nil), services...)
+	sort.Strings(sorted)
+	broadcast := broadcastAddr(p).String()
+	index := 0
+	for _, service := range sorted {
+		if _, ok := result[service]; ok {
+			continue
+		}
+		for {
+			ip := GetContainerIP(p.String(), index)
+			index++
+			if ip == "" || ip == broadcast {
+				return nil, fmt.Errorf("no free addresses left in %s for service '%s'", p, service)
+			}
+			if reserve(service, ip) {
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// broadcastAddr returns the last address of an IPv4 prefix
+func broadcastAddr(p netip.Prefix) netip.Addr {
+	a := p.Masked().Addr().As4()
+	hostBits := 32 - p.Bits()
+	for i := 3; i >= 0 && hostBits > 0; i-- {
+		bits := hostBits
+		if bits > 8 {
+			bits = 8
+		}
+		a[i] |= byte(1<<bits - 1)
+		hostBits -= bits
+	}
+	return netip.AddrFrom4(a)
+}
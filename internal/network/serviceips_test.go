@@ -0,0 +1,119 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAssignServiceIPs(t *testing.T) {
+	tests := []struct {
+		name     string
+		subnet   string
+		services []string
+		static   map[string]string
+		previous map[string]string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "name order",
+			subnet:   "172.18.0.0/24",
+			services: []string{"web", "db"},
+			want:     map[string]string{"db": "172.18.0.2", "web": "172.18.0.3"},
+		},
+		{
+			name:     "previous kept when a service is added",
+			subnet:   "172.18.0.0/24",
+			services: []string{"web", "db", "cache"},
+			previous: map[string]string{"db": "172.18.0.2", "web": "172.18.0.3"},
+			want:     map[string]string{"db": "172.18.0.2", "web": "172.18.0.3", "cache": "172.18.0.4"},
+		},
+		{
+			name:     "inactive services keep their address",
+			subnet:   "172.18.0.0/24",
+			services: []string{"web", "cache"},
+			previous: map[string]string{"db": "172.18.0.2", "web": "172.18.0.3"},
+			want:     map[string]string{"db": "172.18.0.2", "web": "172.18.0.3", "cache": "172.18.0.4"},
+		},
+		{
+			name:     "static IPs win",
+			subnet:   "172.18.0.0/24",
+			services: []string{"web", "db"},
+			static:   map[string]string{"db": "172.18.0.3"},
+			previous: map[string]string{"web": "172.18.0.3"},
+			want:     map[string]string{"db": "172.18.0.3", "web": "172.18.0.2"},
+		},
+		{
+			name:     "previous outside a new subnet reassigned",
+			subnet:   "10.210.0.0/28",
+			services: []string{"web"},
+			previous: map[string]string{"web": "172.18.0.3"},
+			want:     map[string]string{"web": "10.210.0.2"},
+		},
+		{
+			name:     "exhausted",
+			subnet:   "10.210.0.0/30",
+			services: []string{"a", "b"},
+			wantErr:  true,
+		},
+		{
+			name:    "invalid subnet",
+			subnet:  "invalid",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AssignServiceIPs(tt.subnet, tt.services, tt.static, tt.previous)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AssignServiceIPs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AssignServiceIPs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectManager_SetServiceIPs(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(tmpDir, "projects.json"),
+		projects: map[string]ProjectInfo{
+			"shop": {Path: "/shop", Subnet: "172.18.0.0/16"},
+		},
+	}
+
+	ips := map[string]string{"web": "172.18.0.2"}
+	if err := mgr.SetServiceIPs("shop", ips); err != nil {
+		t.Fatalf("SetServiceIPs() error = %v", err)
+	}
+	if err := mgr.SetServiceIPs("missing", ips); err == nil {
+		t.Error("SetServiceIPs() should fail for an unregistered project")
+	}
+
+	reloaded := &ProjectManager{globalPath: mgr.globalPath, projects: make(map[string]ProjectInfo)}
+	if err := reloaded.loadGlobal(); err != nil {
+		t.Fatalf("loadGlobal() error = %v", err)
+	}
+	if got := reloaded.projects["shop"].ServiceIPs; !reflect.DeepEqual(got, ips) {
+		t.Errorf("ServiceIPs = %v, want %v", got, ips)
+	}
+
+	// Removing the project removes its override
+	override := mgr.OverridePath("shop")
+	if override != filepath.Join(tmpDir, "overrides", "shop.yml") {
+		t.Errorf("OverridePath() = %q", override)
+	}
+	os.MkdirAll(filepath.Dir(override), 0755)
+	os.WriteFile(override, []byte("services: {}\n"), 0644)
+	if err := mgr.RemoveProject("shop"); err != nil {
+		t.Fatalf("RemoveProject() error = %v", err)
+	}
+	if _, err := os.Stat(override); !os.IsNotExist(err) {
+		t.Error("override should be removed with the project")
+	}
+}
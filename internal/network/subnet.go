@@ -1,8 +1,8 @@
 package network
 
 import (
-	"encoding/json"
 	"fmt"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,15 +11,14 @@ import (
 const (
 	globalConfigDir  = ".bootapp"
 	globalConfigFile = "projects.json"
-	// Subnet range: 172.18.0.0/16 to 172.31.0.0/16
-	subnetStart = 18
-	subnetEnd   = 31
+	overridesDir     = "overrides"
 )
 
 // ContainerInfo stores individual container's domains and IP
 type ContainerInfo struct {
 	Domains []string `json:"domains,omitempty"`
 	IP      string   `json:"ip"`
+	IPv6    string   `json:"ipv6,omitempty"`
 }
 
 // ProjectInfo stores global project information
@@ -28,15 +27,44 @@ type ProjectInfo struct {
 	Subnet     string   `json:"subnet"`
 	Domains    []string `json:"domains,omitempty"`
 	SSLDomains []string `json:"ssl_domains,omitempty"`
-
-	// Deprecated: use Domains instead (kept for backward compatibility)
-	Domain string `json:"domain,omitempty"`
+	// Pinned is set when the subnet comes from x-bootapp.subnet
+	Pinned bool `json:"pinned,omitempty"`
+	// ServiceIPs maps service name to its address on the routable network
+	ServiceIPs map[string]string `json:"service_ips,omitempty"`
+	// Subnet6 is the IPv6 /64 of the routable network (x-bootapp.ipv6)
+	Subnet6 string `json:"subnet6,omitempty"`
+	// BaseName is the name this project was suffixed from when another
+	// directory had already registered it
+	BaseName string `json:"base_name,omitempty"`
+	// Instance is the git worktree or branch instance (x-bootapp.instance)
+	Instance string `json:"instance,omitempty"`
 }
 
 // ProjectManager manages project configurations
 type ProjectManager struct {
 	globalPath string
 	projects   map[string]ProjectInfo
+	settings   map[string]any
+	config     Config
+	configPath string
+	// original is the registry as read, kept while it needs migrating from
+	// version migratedFrom so the first save can back it up
+	original     []byte
+	migratedFrom int
+	// hostSubnets are ranges in use outside bootapp, skipped by allocation
+	hostSubnets []UsedSubnet
+	// allowSuffix lets a name registered for another directory be suffixed
+	// instead of refused (see ResolveProject)
+	allowSuffix bool
+}
+
+// RegistryPath returns the path of the project registry (~/.bootapp/projects.json)
+func RegistryPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, globalConfigDir, globalConfigFile), nil
 }
 
 // NewProjectManager creates a new project manager
@@ -48,12 +76,21 @@ func NewProjectManager() (*ProjectManager, error) {
 
 	globalPath := filepath.Join(homeDir, globalConfigDir, globalConfigFile)
 
+	configPath := filepath.Join(homeDir, globalConfigDir, userConfigFile)
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
 	mgr := &ProjectManager{
 		globalPath: globalPath,
 		projects:   make(map[string]ProjectInfo),
+		config:     config,
+		configPath: configPath,
 	}
 
-	// Load existing configuration
+	// Load existing configuration; an older schema is migrated in memory and
+	// written by the first change, so read-only commands never rewrite it
 	if err := mgr.loadGlobal(); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
@@ -67,21 +104,68 @@ type ProjectChanges struct {
 	PreviousSSLDomains []string
 	DomainChanged      bool
 	RemovedSSLDomains  []string
+	// PreviousSubnet is set when a pinned subnet, or a subnet from the
+	// configured pools, replaced the stored one
+	PreviousSubnet string
+	// SuffixedFrom is set when another directory registered the name first
+	// and the project was registered under a suffixed name instead
+	SuffixedFrom string
+	// Name is the name the project is registered under
+	Name string
 }
 
 // GetOrCreateProject returns existing project or creates a new one
 // Also returns changes detected from previous config
 func (m *ProjectManager) GetOrCreateProject(projectName, projectPath string, domains []string, sslDomains []string) (*ProjectInfo, *ProjectChanges, error) {
-	changes := &ProjectChanges{}
+	return m.GetOrCreateProjectWithSubnet(projectName, projectPath, domains, sslDomains, "")
+}
+
+// GetOrCreateProjectWithSubnet is GetOrCreateProject with a pinned subnet
+// An empty subnet keeps the stored subnet or allocates a new one
+func (m *ProjectManager) GetOrCreateProjectWithSubnet(projectName, projectPath string, domains []string, sslDomains []string, subnet string) (*ProjectInfo, *ProjectChanges, error) {
+	var info *ProjectInfo
+	var changes *ProjectChanges
+	err := m.update(func() (bool, error) {
+		var changed bool
+		var err error
+		info, changes, changed, err = m.getOrCreateProject(projectName, projectPath, domains, sslDomains, subnet)
+		return changed, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, changes, nil
+}
+
+// getOrCreateProject updates or registers a project in memory and reports
+// whether the registry changed
+func (m *ProjectManager) getOrCreateProject(projectName, projectPath string, domains []string, sslDomains []string, subnet string) (*ProjectInfo, *ProjectChanges, bool, error) {
+	changes := &ProjectChanges{Name: projectName}
+
+	// Another directory may have registered the name since it was resolved
+	if info, ok := m.projects[projectName]; ok && collides(info.Path, projectPath) {
+		resolved, suffixed, err := m.ResolveProject(projectName, projectPath, m.allowSuffix)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if suffixed {
+			changes.SuffixedFrom = projectName
+		}
+		projectName = resolved
+		changes.Name = projectName
+	}
+
+	if subnet != "" {
+		var err error
+		if subnet, err = m.checkPinnedSubnet(projectName, subnet); err != nil {
+			return nil, nil, false, err
+		}
+	}
 
 	// Check if project exists
 	if info, ok := m.projects[projectName]; ok {
 		// Capture previous values for change detection
-		// Support old Domain field for backward compatibility
 		prevDomains := info.Domains
-		if len(prevDomains) == 0 && info.Domain != "" {
-			prevDomains = []string{info.Domain}
-		}
 		if len(prevDomains) > 0 {
 			changes.PreviousDomain = strings.Join(prevDomains, ", ")
 		}
@@ -95,7 +179,7 @@ func (m *ProjectManager) GetOrCreateProject(projectName, projectPath string, dom
 		// Find removed SSL domains
 		changes.RemovedSSLDomains = findRemovedDomains(info.SSLDomains, sslDomains)
 
-		// Update all fields
+		// Update all fields; the path only changes when the old directory is gone
 		needSave := false
 		if info.Path != projectPath {
 			info.Path = projectPath
@@ -103,24 +187,44 @@ func (m *ProjectManager) GetOrCreateProject(projectName, projectPath string, dom
 		}
 		if !slicesEqual(info.Domains, domains) {
 			info.Domains = domains
-			info.Domain = "" // Clear deprecated field
 			needSave = true
 		}
 		if !slicesEqual(info.SSLDomains, sslDomains) {
 			info.SSLDomains = sslDomains
 			needSave = true
 		}
+		if subnet != "" && info.Subnet != subnet {
+			changes.PreviousSubnet = info.Subnet
+			info.Subnet = subnet
+			needSave = true
+		}
+		// Move subnets outside the configured pools (or no longer pinned) into them
+		if subnet == "" && !m.config.InPools(info.Subnet) {
+			newSubnet, err := m.allocateSubnet()
+			if err != nil {
+				return nil, nil, false, err
+			}
+			changes.PreviousSubnet = info.Subnet
+			info.Subnet = newSubnet
+			needSave = true
+		}
+		if pinned := subnet != ""; info.Pinned != pinned {
+			info.Pinned = pinned
+			needSave = true
+		}
 		if needSave {
 			m.projects[projectName] = info
-			m.saveGlobal()
 		}
-		return &info, changes, nil
+		return &info, changes, needSave, nil
 	}
 
-	// Allocate new subnet
-	subnet, err := m.allocateSubnet()
-	if err != nil {
-		return nil, nil, err
+	// Allocate new subnet unless pinned
+	pinned := subnet != ""
+	if subnet == "" {
+		var err error
+		if subnet, err = m.allocateSubnet(); err != nil {
+			return nil, nil, false, err
+		}
 	}
 
 	// Create project info
@@ -129,15 +233,12 @@ func (m *ProjectManager) GetOrCreateProject(projectName, projectPath string, dom
 		Subnet:     subnet,
 		Domains:    domains,
 		SSLDomains: sslDomains,
+		Pinned:     pinned,
+		BaseName:   changes.SuffixedFrom,
 	}
 	m.projects[projectName] = info
 
-	// Save global config
-	if err := m.saveGlobal(); err != nil {
-		return nil, nil, err
-	}
-
-	return &info, changes, nil
+	return &info, changes, true, nil
 }
 
 // findRemovedDomains returns domains that were in old but not in new
@@ -189,81 +290,306 @@ func (m *ProjectManager) ListProjects() map[string]ProjectInfo {
 	return result
 }
 
-// RemoveProject removes a project
+// RemoveProject removes a project and its generated compose override
 func (m *ProjectManager) RemoveProject(projectName string) error {
-	delete(m.projects, projectName)
-	return m.saveGlobal()
+	if err := os.Remove(m.OverridePath(projectName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return m.update(func() (bool, error) {
+		delete(m.projects, projectName)
+		return true, nil
+	})
 }
 
+// SetServiceIPs stores the service addresses of a registered project
+func (m *ProjectManager) SetServiceIPs(projectName string, serviceIPs map[string]string) error {
+	return m.update(func() (bool, error) {
+		info, ok := m.projects[projectName]
+		if !ok {
+			return false, fmt.Errorf("project '%s' is not registered", projectName)
+		}
+		info.ServiceIPs = serviceIPs
+		m.projects[projectName] = info
+		return true, nil
+	})
+}
+
+// SetIPv6 gives a registered project an IPv6 /64 from the machine's ULA prefix,
+// or takes it away, and returns the project's IPv6 subnet ("" when disabled)
+// The ULA prefix is generated and saved to config.json on first use
+func (m *ProjectManager) SetIPv6(projectName string, enabled bool) (string, error) {
+	var subnet6 string
+	err := m.update(func() (bool, error) {
+		var changed bool
+		var err error
+		subnet6, changed, err = m.setIPv6(projectName, enabled)
+		return changed, err
+	})
+	return subnet6, err
+}
+
+func (m *ProjectManager) setIPv6(projectName string, enabled bool) (string, bool, error) {
+	info, ok := m.projects[projectName]
+	if !ok {
+		return "", false, fmt.Errorf("project '%s' is not registered", projectName)
+	}
+
+	if !enabled {
+		if info.Subnet6 != "" {
+			info.Subnet6 = ""
+			m.projects[projectName] = info
+			return "", true, nil
+		}
+		return "", false, nil
+	}
+
+	if err := m.ensureULAPrefix(); err != nil {
+		return "", false, err
+	}
+	ula, err := m.config.ULA()
+	if err != nil {
+		return "", false, err
+	}
+
+	// Keep the current /64 while it is inside the ULA prefix
+	if p, err := netip.ParsePrefix(info.Subnet6); err == nil && p.Bits() == projectPrefix6 && ula.Contains(p.Addr()) {
+		return info.Subnet6, false, nil
+	}
+
+	used := make(map[netip.Prefix]bool)
+	for _, other := range m.projects {
+		if p, err := netip.ParsePrefix(other.Subnet6); err == nil {
+			used[p.Masked()] = true
+		}
+	}
+	candidate := netip.PrefixFrom(ula.Addr(), projectPrefix6)
+	for used[candidate] {
+		next, ok := nextPrefix(candidate)
+		if !ok || !ula.Contains(next.Addr()) {
+			return "", false, fmt.Errorf("no available /%d subnets in %s", projectPrefix6, ula)
+		}
+		candidate = next
+	}
+
+	info.Subnet6 = candidate.String()
+	m.projects[projectName] = info
+	return info.Subnet6, true, nil
+}
+
+// ensureULAPrefix generates and saves the machine's ULA prefix if there is none
+// Called with the registry locked, so concurrent runs agree on one prefix
+func (m *ProjectManager) ensureULAPrefix() error {
+	if m.config.ULAPrefix != "" {
+		return nil
+	}
+	if m.configPath != "" {
+		// Another run may have generated it since this one started
+		config, err := LoadConfig(m.configPath)
+		if err != nil {
+			return err
+		}
+		if config.ULAPrefix != "" {
+			m.config.ULAPrefix = config.ULAPrefix
+			return nil
+		}
+	}
+
+	prefix, err := GenerateULAPrefix()
+	if err != nil {
+		return fmt.Errorf("failed to generate ULA prefix: %w", err)
+	}
+	m.config.ULAPrefix = prefix
+	if m.configPath == "" {
+		return nil
+	}
+	return SaveConfig(m.configPath, m.config)
+}
+
+// OverridePath returns the path of the compose override bootapp generates for
+// a project (~/.bootapp/overrides/<project>.yml)
+func (m *ProjectManager) OverridePath(projectName string) string {
+	return filepath.Join(filepath.Dir(m.globalPath), overridesDir, projectName+".yml")
+}
+
+// checkPinnedSubnet validates a pinned subnet, makes sure it overlaps no other
+// project's subnet and returns its masked form (172.20.0.1/16 -> 172.20.0.0/16)
+func (m *ProjectManager) checkPinnedSubnet(projectName, subnet string) (string, error) {
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return "", fmt.Errorf("invalid subnet %q: %w", subnet, err)
+	}
+	p = p.Masked()
+	for _, name := range sortedProjectNames(m.projects) {
+		if name == projectName {
+			continue
+		}
+		other, err := netip.ParsePrefix(m.projects[name].Subnet)
+		if err != nil {
+			continue
+		}
+		if p.Overlaps(other) {
+			return "", fmt.Errorf("subnet %s overlaps %s of project '%s'", p, other, name)
+		}
+	}
+	return p.String(), nil
+}
+
+// AllowNameSuffix lets GetOrCreateProject register a name that another
+// directory took first under a suffixed name, instead of failing
+func (m *ProjectManager) AllowNameSuffix(allow bool) {
+	m.allowSuffix = allow
+}
+
+// SetHostSubnets sets the host ranges (see HostSubnets) new subnets must avoid
+func (m *ProjectManager) SetHostSubnets(used []UsedSubnet) {
+	m.hostSubnets = used
+}
+
+// NeedsMigration reports whether a project's allocated subnet is outside the
+// configured pools, so the next up moves it
+func (m *ProjectManager) NeedsMigration(info ProjectInfo) bool {
+	return !info.Pinned && !m.config.InPools(info.Subnet)
+}
+
+// allocateSubnet returns the first subnet of the configured size, in pool
+// order, that does not overlap a registered project or a host range
 func (m *ProjectManager) allocateSubnet() (string, error) {
-	usedNumbers := make(map[int]bool)
+	pools, err := m.config.Pools()
+	if err != nil {
+		return "", err
+	}
+	bits := m.config.Prefix()
+
+	var used []netip.Prefix
 	for _, info := range m.projects {
-		num := extractSubnetNumber(info.Subnet)
-		if num > 0 {
-			usedNumbers[num] = true
+		if p, err := netip.ParsePrefix(info.Subnet); err == nil {
+			used = append(used, p.Masked())
 		}
 	}
+	for _, u := range m.hostSubnets {
+		used = append(used, u.Prefix)
+	}
 
-	for i := subnetStart; i <= subnetEnd; i++ {
-		if !usedNumbers[i] {
-			return fmt.Sprintf("172.%d.0.0/16", i), nil
+	for _, pool := range pools {
+		candidate := netip.PrefixFrom(pool.Addr(), bits)
+		for pool.Contains(candidate.Addr()) {
+			if !overlapsAny(candidate, used) {
+				return candidate.String(), nil
+			}
+			next, ok := nextPrefix(candidate)
+			if !ok {
+				break
+			}
+			candidate = next
 		}
 	}
 
-	return "", fmt.Errorf("no available subnets (all %d-%d in use)", subnetStart, subnetEnd)
+	return "", fmt.Errorf("no available /%d subnets in pools %s", bits, strings.Join(poolStrings(pools), ", "))
+}
+
+func overlapsAny(p netip.Prefix, prefixes []netip.Prefix) bool {
+	for _, other := range prefixes {
+		if p.Overlaps(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func poolStrings(pools []netip.Prefix) []string {
+	result := make([]string, len(pools))
+	for i, pool := range pools {
+		result[i] = pool.String()
+	}
+	return result
+}
+
+// update locks the registry, reloads it so changes made by other bootapp runs
+// are kept, applies fn and saves when fn reports a change, migrating the file
+// (with a backup) if it is older
+func (m *ProjectManager) update(fn func() (bool, error)) error {
+	return withFileLock(m.globalPath, func() error {
+		if err := m.loadGlobal(); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		changed, err := fn()
+		if err != nil || !changed {
+			return err
+		}
+		return m.saveGlobal()
+	})
 }
 
+// loadGlobal reads the registry, migrating older schema versions in memory
 func (m *ProjectManager) loadGlobal() error {
 	data, err := os.ReadFile(m.globalPath)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, &m.projects)
+	registry, result, err := MigrateRegistry(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.globalPath, err)
+	}
+	m.projects = registry.Projects
+	m.settings = registry.Settings
+	m.original, m.migratedFrom = nil, 0
+	if len(result.Applied) > 0 {
+		m.original, m.migratedFrom = data, result.From
+	}
+	return nil
 }
 
 func (m *ProjectManager) saveGlobal() error {
-	dir := filepath.Dir(m.globalPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(m.projects, "", "  ")
-	if err != nil {
+	registry := &Registry{Projects: m.projects, Settings: m.settings}
+	if err := writeRegistry(m.globalPath, m.original, m.migratedFrom, registry); err != nil {
 		return err
 	}
-	return os.WriteFile(m.globalPath, data, 0644)
+	m.original, m.migratedFrom = nil, 0
+	return nil
 }
 
-func extractSubnetNumber(subnet string) int {
-	parts := strings.Split(subnet, ".")
-	if len(parts) >= 2 {
-		var num int
-		fmt.Sscanf(parts[1], "%d", &num)
-		return num
+// ServiceIPv6 returns the IPv6 address of a service on an IPv6 /64: the
+// subnet with the service's IPv4 address as its last 32 bits, so both stay stable
+func ServiceIPv6(subnet6, ipv4 string) string {
+	p, err := netip.ParsePrefix(subnet6)
+	if err != nil || !p.Addr().Is6() || p.Bits() > 96 {
+		return ""
 	}
-	return 0
+	v4, err := netip.ParseAddr(ipv4)
+	if err != nil || !v4.Is4() {
+		return ""
+	}
+	a := p.Masked().Addr().As16()
+	b := v4.As4()
+	copy(a[12:], b[:])
+	return netip.AddrFrom16(a).String()
 }
 
-// GetDefaultIP returns the default app IP for a subnet (x.x.0.2)
+// GetDefaultIP returns the default app IP for a subnet (the first host after the gateway)
 func GetDefaultIP(subnet string) string {
-	parts := strings.Split(subnet, "/")
-	if len(parts) > 0 {
-		ipParts := strings.Split(parts[0], ".")
-		if len(ipParts) == 4 {
-			return fmt.Sprintf("%s.%s.0.2", ipParts[0], ipParts[1])
-		}
-	}
-	return ""
+	return GetContainerIP(subnet, 0)
 }
 
-// GetContainerIP returns IP for a specific container index
+// GetContainerIP returns IP for a specific container index, counting from the
+// address after the gateway (.2 of a /24 or /16)
+// Returns "" when the index is outside the subnet
 func GetContainerIP(subnet string, index int) string {
-	parts := strings.Split(subnet, "/")
-	if len(parts) > 0 {
-		ipParts := strings.Split(parts[0], ".")
-		if len(ipParts) == 4 {
-			return fmt.Sprintf("%s.%s.0.%d", ipParts[0], ipParts[1], index+2)
+	p, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		addr, err := netip.ParseAddr(subnet)
+		if err != nil {
+			return ""
 		}
+		p = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	p = p.Masked()
+
+	addr := p.Addr().Next()
+	for i := 0; i <= index && addr.IsValid(); i++ {
+		addr = addr.Next()
+	}
+	if !addr.IsValid() || (p.Bits() < addr.BitLen() && !p.Contains(addr)) {
+		return ""
 	}
-	return ""
+	return addr.String()
 }
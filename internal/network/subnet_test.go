@@ -6,31 +6,6 @@ import (
 	"testing"
 )
 
-func TestExtractSubnetNumber(t *testing.T) {
-	tests := []struct {
-		name     string
-		subnet   string
-		expected int
-	}{
-		{"valid subnet 18", "172.18.0.0/16", 18},
-		{"valid subnet 19", "172.19.0.0/16", 19},
-		{"valid subnet 31", "172.31.0.0/16", 31},
-		{"different prefix", "192.168.0.0/24", 168},
-		{"empty string", "", 0},
-		{"invalid format", "invalid", 0},
-		{"single part", "172", 0},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractSubnetNumber(tt.subnet)
-			if result != tt.expected {
-				t.Errorf("extractSubnetNumber(%q) = %d, want %d", tt.subnet, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestGetDefaultIP(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -65,6 +40,8 @@ func TestGetContainerIP(t *testing.T) {
 		{"second container", "172.18.0.0/16", 1, "172.18.0.3"},
 		{"tenth container", "172.18.0.0/16", 8, "172.18.0.10"},
 		{"different subnet", "172.19.0.0/16", 0, "172.19.0.2"},
+		{"network sub-range", "172.18.3.0/24", 1, "172.18.3.3"},
+		{"past the subnet", "172.18.3.0/28", 14, ""},
 	}
 
 	for _, tt := range tests {
@@ -145,19 +122,10 @@ func TestProjectManager_AllocateSubnet_Exhausted(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Fill all available subnets (18-31)
+	// Fill all available subnets in the default pools (18-31)
 	projects := make(map[string]ProjectInfo)
-	for i := subnetStart; i <= subnetEnd; i++ {
-		projects[string(rune('a'+i-subnetStart))] = ProjectInfo{
-			Subnet: GetContainerIP("172."+string(rune('0'+i/10))+string(rune('0'+i%10))+".0.0/16", -2),
-		}
-	}
-
-	// Manually set correct subnets
-	projects = make(map[string]ProjectInfo)
-	for i := subnetStart; i <= subnetEnd; i++ {
-		name := string(rune('a' + i - subnetStart))
-		projects[name] = ProjectInfo{
+	for i := 18; i <= 31; i++ {
+		projects["project"+itoa(i)] = ProjectInfo{
 			Subnet: "172." + itoa(i) + ".0.0/16",
 		}
 	}
@@ -225,9 +193,9 @@ func TestProjectManager_GetOrCreateProject_Existing(t *testing.T) {
 		globalPath: filepath.Join(tmpDir, "projects.json"),
 		projects: map[string]ProjectInfo{
 			"existing": {
-				Path:   "/old/path",
-				Subnet: "172.20.0.0/16",
-				Domain: "existing.local",
+				Path:    "/old/path",
+				Subnet:  "172.20.0.0/16",
+				Domains: []string{"existing.local"},
 			},
 		},
 	}
@@ -255,9 +223,9 @@ func TestProjectManager_GetOrCreateProject_UpdatePath(t *testing.T) {
 		globalPath: filepath.Join(tmpDir, "projects.json"),
 		projects: map[string]ProjectInfo{
 			"myproject": {
-				Path:   "/old/path",
-				Subnet: "172.20.0.0/16",
-				Domain: "myproject.local",
+				Path:    "/old/path",
+				Subnet:  "172.20.0.0/16",
+				Domains: []string{"myproject.local"},
 			},
 		},
 	}
@@ -305,6 +273,67 @@ func TestProjectManager_RemoveProject(t *testing.T) {
 	}
 }
 
+func TestProjectManager_GetOrCreateProjectWithSubnet(t *testing.T) {
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(t.TempDir(), "projects.json"),
+		projects: map[string]ProjectInfo{
+			"other":    {Path: "/other", Subnet: "172.18.0.0/16"},
+			"existing": {Path: "/existing", Subnet: "172.19.0.0/16"},
+		},
+	}
+
+	// New project takes the pinned subnet instead of allocating one
+	info, _, err := mgr.GetOrCreateProjectWithSubnet("pinned", "/pinned", nil, nil, "10.50.0.0/16")
+	if err != nil {
+		t.Fatalf("GetOrCreateProjectWithSubnet() error = %v", err)
+	}
+	if info.Subnet != "10.50.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", info.Subnet, "10.50.0.0/16")
+	}
+
+	// Existing project is moved to the pinned subnet
+	info, changes, err := mgr.GetOrCreateProjectWithSubnet("existing", "/existing", nil, nil, "172.25.0.0/16")
+	if err != nil {
+		t.Fatalf("GetOrCreateProjectWithSubnet() error = %v", err)
+	}
+	if info.Subnet != "172.25.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", info.Subnet, "172.25.0.0/16")
+	}
+	if changes.PreviousSubnet != "172.19.0.0/16" {
+		t.Errorf("PreviousSubnet = %q, want %q", changes.PreviousSubnet, "172.19.0.0/16")
+	}
+
+	// Subnet used by another project
+	if _, _, err := mgr.GetOrCreateProjectWithSubnet("new", "/new", nil, nil, "172.18.0.0/16"); err == nil {
+		t.Error("GetOrCreateProjectWithSubnet() should fail for a subnet used by another project")
+	}
+
+	// Subnet overlapping another project's
+	if _, _, err := mgr.GetOrCreateProjectWithSubnet("new", "/new", nil, nil, "172.18.5.0/24"); err == nil {
+		t.Error("GetOrCreateProjectWithSubnet() should fail for a subnet inside another project's")
+	}
+	if _, _, err := mgr.GetOrCreateProjectWithSubnet("new", "/new", nil, nil, "172.0.0.0/8"); err == nil {
+		t.Error("GetOrCreateProjectWithSubnet() should fail for a subnet containing another project's")
+	}
+
+	// Unmasked subnets are stored masked
+	info, _, err = mgr.GetOrCreateProjectWithSubnet("unmasked", "/unmasked", nil, nil, "10.60.0.1/16")
+	if err != nil {
+		t.Fatalf("GetOrCreateProjectWithSubnet() error = %v", err)
+	}
+	if info.Subnet != "10.60.0.0/16" {
+		t.Errorf("Subnet = %q, want %q", info.Subnet, "10.60.0.0/16")
+	}
+	if _, _, err := mgr.GetOrCreateProjectWithSubnet("new", "/new", nil, nil, "10.60.0.0/16"); err == nil {
+		t.Error("GetOrCreateProjectWithSubnet() should fail for a masked subnet already in use")
+	}
+
+	// Invalid CIDR
+	if _, _, err := mgr.GetOrCreateProjectWithSubnet("new", "/new", nil, nil, "172.18.0.0"); err == nil {
+		t.Error("GetOrCreateProjectWithSubnet() should fail for an invalid subnet")
+	}
+}
+
 func TestProjectManager_ListProjects(t *testing.T) {
 	mgr := &ProjectManager{
 		projects: map[string]ProjectInfo{
@@ -362,8 +391,8 @@ func TestProjectManager_SaveAndLoadGlobal(t *testing.T) {
 	mgr1 := &ProjectManager{
 		globalPath: globalPath,
 		projects: map[string]ProjectInfo{
-			"project1": {Path: "/path1", Subnet: "172.18.0.0/16", Domain: "p1.local"},
-			"project2": {Path: "/path2", Subnet: "172.19.0.0/16", Domain: "p2.local"},
+			"project1": {Path: "/path1", Subnet: "172.18.0.0/16", Domains: []string{"p1.local"}},
+			"project2": {Path: "/path2", Subnet: "172.19.0.0/16", Domains: []string{"p2.local"}},
 		},
 	}
 	err = mgr1.saveGlobal()
@@ -433,3 +462,132 @@ func TestNewProjectManager(t *testing.T) {
 		t.Error("projects map should be initialized")
 	}
 }
+
+func TestProjectManager_SetIPv6(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(tmpDir, "projects.json"),
+		projects: map[string]ProjectInfo{
+			"shop":  {Subnet: "172.18.0.0/16"},
+			"blog":  {Subnet: "172.19.0.0/16"},
+			"other": {Subnet: "172.20.0.0/16", Subnet6: "fd12:3456:789a::/64"},
+		},
+		config:     Config{ULAPrefix: "fd12:3456:789a::/48"},
+		configPath: filepath.Join(tmpDir, "config.json"),
+	}
+
+	subnet6, err := mgr.SetIPv6("shop", true)
+	if err != nil {
+		t.Fatalf("SetIPv6() error = %v", err)
+	}
+	if subnet6 != "fd12:3456:789a:1::/64" {
+		t.Errorf("subnet6 = %q, want %q", subnet6, "fd12:3456:789a:1::/64")
+	}
+
+	// Stable across runs
+	if again, _ := mgr.SetIPv6("shop", true); again != subnet6 {
+		t.Errorf("second SetIPv6() = %q, want %q", again, subnet6)
+	}
+
+	if subnet6, _ := mgr.SetIPv6("blog", true); subnet6 != "fd12:3456:789a:2::/64" {
+		t.Errorf("blog subnet6 = %q, want %q", subnet6, "fd12:3456:789a:2::/64")
+	}
+
+	if subnet6, err := mgr.SetIPv6("shop", false); err != nil || subnet6 != "" {
+		t.Errorf("SetIPv6(false) = %q, %v", subnet6, err)
+	}
+	if mgr.projects["shop"].Subnet6 != "" {
+		t.Error("Subnet6 should be cleared when IPv6 is disabled")
+	}
+}
+
+func TestProjectManager_SetIPv6_GeneratesULAPrefix(t *testing.T) {
+	tmpDir := t.TempDir()
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(tmpDir, "projects.json"),
+		projects:   map[string]ProjectInfo{"shop": {Subnet: "172.18.0.0/16"}},
+		configPath: filepath.Join(tmpDir, "config.json"),
+	}
+
+	if _, err := mgr.SetIPv6("shop", true); err != nil {
+		t.Fatalf("SetIPv6() error = %v", err)
+	}
+
+	config, err := LoadConfig(mgr.configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if config.ULAPrefix == "" || config.ULAPrefix != mgr.config.ULAPrefix {
+		t.Errorf("saved ula_prefix = %q, want %q", config.ULAPrefix, mgr.config.ULAPrefix)
+	}
+}
+
+func TestServiceIPv6(t *testing.T) {
+	tests := []struct {
+		subnet6 string
+		ipv4    string
+		want    string
+	}{
+		{"fd12:3456:789a:1::/64", "172.18.0.2", "fd12:3456:789a:1::ac12:2"},
+		{"fd12:3456:789a:1::/64", "10.210.3.17", "fd12:3456:789a:1::ad2:311"},
+		{"", "172.18.0.2", ""},
+		{"fd12:3456:789a:1::/64", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ServiceIPv6(tt.subnet6, tt.ipv4); got != tt.want {
+			t.Errorf("ServiceIPv6(%q, %q) = %q, want %q", tt.subnet6, tt.ipv4, got, tt.want)
+		}
+	}
+}
+
+func TestProjectManager_ConcurrentAllocation(t *testing.T) {
+	globalPath := filepath.Join(t.TempDir(), "projects.json")
+
+	// Each goroutine stands in for a separate bootapp run with its own view
+	const runs = 8
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		go func(i int) {
+			mgr := &ProjectManager{globalPath: globalPath, projects: make(map[string]ProjectInfo)}
+			_, _, err := mgr.GetOrCreateProject("project"+itoa(i), "/project"+itoa(i), nil, nil)
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < runs; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("GetOrCreateProject() error = %v", err)
+		}
+	}
+
+	mgr := &ProjectManager{globalPath: globalPath, projects: make(map[string]ProjectInfo)}
+	if err := mgr.loadGlobal(); err != nil {
+		t.Fatalf("loadGlobal() error = %v", err)
+	}
+	if len(mgr.projects) != runs {
+		t.Fatalf("registered %d projects, want %d", len(mgr.projects), runs)
+	}
+	subnets := make(map[string]string)
+	for name, info := range mgr.projects {
+		if other, ok := subnets[info.Subnet]; ok {
+			t.Errorf("%s and %s share subnet %s", name, other, info.Subnet)
+		}
+		subnets[info.Subnet] = name
+	}
+}
+
+func TestProjectManager_SaveErrorPropagated(t *testing.T) {
+	dir := t.TempDir()
+	mgr := &ProjectManager{
+		globalPath: filepath.Join(dir, "projects.json"),
+		projects:   map[string]ProjectInfo{"shop": {Path: "/shop", Subnet: "172.18.0.0/16"}},
+	}
+
+	// A directory in place of the registry file makes the update fail
+	if err := os.Mkdir(mgr.globalPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := mgr.GetOrCreateProject("shop", "/moved", nil, nil); err == nil {
+		t.Error("GetOrCreateProject() should return the registry error for an existing project")
+	}
+}
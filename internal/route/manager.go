@@ -50,6 +50,19 @@ func checkConnectivity(testIP string) bool {
 	return cmd.Run() == nil
 }
 
+// IPv6Reachable reports whether the host can reach a container's IPv6 address
+// Linux reaches Docker's IPv6 networks directly; on macOS docker-mac-net-connect
+// and OrbStack only route IPv4, so the address is tested
+func IPv6Reachable(testIP string) bool {
+	if testIP == "" {
+		return false
+	}
+	if IsLinux() {
+		return true
+	}
+	return exec.Command("ping6", "-c", "1", testIP).Run() == nil
+}
+
 // SetupRouteWithTest checks routing with optional connectivity test
 func SetupRouteWithTest(subnet, testIP string) error {
 	if IsLinux() {